@@ -0,0 +1,89 @@
+// Copyright (c) 2015, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package check
+
+import (
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/buildssa"
+	"golang.org/x/tools/go/ssa"
+)
+
+// Analyzer reports parameters whose types are more specific than
+// necessary, suggesting interface types that they could use instead.
+var Analyzer = &analysis.Analyzer{
+	Name:      "interfacer",
+	Doc:       "suggest narrower interface types for parameters",
+	URL:       "https://pkg.go.dev/mvdan.cc/interfacer/check",
+	Requires:  []*analysis.Analyzer{buildssa.Analyzer},
+	Run:       run,
+	FactTypes: []analysis.Fact{new(ifacesFact)},
+}
+
+// analyzerOpts holds the options set via the analyzer's flags. Only
+// those that make sense for a single package are used.
+var analyzerOpts Options
+
+func init() {
+	Analyzer.Flags.BoolVar(&analyzerOpts.Superset, "superset", false,
+		"suggest the smallest interface containing the used methods if none matches exactly")
+	Analyzer.Flags.BoolVar(&analyzerOpts.Synthesize, "synth", false,
+		"suggest a new interface with the used methods if none fits")
+	Analyzer.Flags.BoolVar(&analyzerOpts.KeepHazards, "hazards", false,
+		"report suggestions that could change behavior at run time, such as with nil checks")
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	// recorded for all packages, as the importers may need them
+	facts := factPkgs(pass)
+	pass.ExportPackageFact(newIfacesFact(pass.Pkg, facts))
+	ssaPkg := pass.ResultOf[buildssa.Analyzer].(*buildssa.SSA)
+	c := &Checker{
+		fset:        pass.Fset,
+		superset:    analyzerOpts.Superset,
+		synth:       analyzerOpts.Synthesize,
+		keepHazards: analyzerOpts.KeepHazards,
+	}
+	c.ssaByPos = make(map[token.Pos]*ssa.Function, len(ssaPkg.SrcFuncs))
+	for _, fn := range ssaPkg.SrcFuncs {
+		if len(fn.Blocks) == 0 { // stub
+			continue
+		}
+		c.ssaByPos[fn.Pos()] = fn
+	}
+	fns := make(map[*ssa.Function]bool, len(ssaPkg.SrcFuncs))
+	for _, fn := range ssaPkg.SrcFuncs {
+		fns[fn] = true
+	}
+	c.findImplemented(fns)
+	var filenames []string
+	for _, f := range pass.Files {
+		filenames = append(filenames, pass.Fset.File(f.Pos()).Name())
+	}
+	cfg, err := c.loadConfig(fileDir(filenames))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.excluded(pass.Pkg.Path()) {
+		return nil, nil
+	}
+	c.pkgConfigs = map[*types.Package]*config{pass.Pkg: cfg}
+	if pass.Module != nil {
+		// only the imports can be searched for interfaces, as no
+		// other packages can be loaded here
+		c.modules = map[*types.Package]string{pass.Pkg: pass.Module.Path}
+	}
+	c.factIfaces = factIfaces(pass, cfg, facts)
+	issues := c.checkPkg(pass.Pkg, pass.TypesInfo, pass.Files)
+	for _, issue := range append(issues, c.unusedDirectives()...) {
+		pass.Report(analysis.Diagnostic{
+			Pos:     issue.Pos(),
+			End:     issue.End(),
+			Message: issue.Message(),
+		})
+	}
+	return nil, nil
+}
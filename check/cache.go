@@ -4,47 +4,200 @@
 package check
 
 import (
-	"go/ast"
 	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/types/typeutil"
 )
 
 type pkgTypes struct {
-	ifaces    map[string]string
-	funcSigns map[string]bool
+	// ifaces maps method sets, as given by funcMap.iface, to the
+	// interfaces that could be suggested for them.
+	ifaces *typeutil.Map
+
+	// generics holds the generic interfaces that could be suggested
+	// once instantiated, as their methods mention type parameters.
+	generics []*types.TypeName
+
+	// sealed holds the interfaces with unexported methods, such as
+	// testing.TB, which only the types in their package can implement.
+	sealed []*types.TypeName
+
+	// namedIfaces holds all the named interfaces in scope, including
+	// the ones that can't be suggested.
+	namedIfaces []*types.TypeName
 }
 
-func (p *pkgTypes) getTypes(pkg *types.Package) {
-	p.ifaces = make(map[string]string)
-	p.funcSigns = make(map[string]bool)
+// getTypes gathers the interfaces in pkg and in its imports, up to the
+// depth set by cfg, skipping those that cfg doesn't allow suggesting.
+// The named interfaces are always gathered from at least two levels of
+// imports, as they are used to avoid false positives.
+//
+// The interfaces in providers are gathered too, regardless of depth,
+// but the closer ones take precedence. Those in packages that pkg can't
+// import, such as internal ones or those importing pkg, are skipped, so
+// that the next best interfaces are suggested instead.
+func (p *pkgTypes) getTypes(pkg *types.Package, cfg *config, providers []*types.Package) {
+	p.ifaces = newIfaceIndex()
+	p.generics = p.generics[:0]
+	p.sealed = p.sealed[:0]
+	p.namedIfaces = p.namedIfaces[:0]
+	maxDepth := max(cfg.ImportDepth, 2)
+
+	// the fewest levels of imports between pkg and each package
+	levels := map[*types.Package]int{pkg: 0}
+	for queue := []*types.Package{pkg}; len(queue) > 0; queue = queue[1:] {
+		other := queue[0]
+		if levels[other] >= maxDepth {
+			continue
+		}
+		for _, imp := range other.Imports() {
+			if _, e := levels[imp]; !e {
+				levels[imp] = levels[other] + 1
+				queue = append(queue, imp)
+			}
+		}
+	}
+
 	done := make(map[*types.Package]bool)
-	addTypes := func(pkg *types.Package, top bool) {
-		if done[pkg] {
+	importers := make(map[*types.Package]bool)
+	addTypes := func(other *types.Package, provider bool) {
+		if done[other] {
 			return
 		}
-		done[pkg] = true
-		ifs, funs := fromScope(pkg.Scope())
-		fullName := func(name string) string {
-			if !top {
-				return pkg.Path() + "." + name
+		done[other] = true
+		ifs, generics, sealed, named := fromScope(other.Scope())
+		if level, e := levels[other]; e && level <= maxDepth {
+			p.namedIfaces = append(p.namedIfaces, named...)
+		}
+		if level, e := levels[other]; !provider && (!e || level > cfg.ImportDepth) {
+			return
+		}
+		if other != pkg && (!importable(other, pkg) || imports(other, pkg, importers)) {
+			return
+		}
+		ifs.Iterate(func(key types.Type, value any) {
+			if tn := value.(*types.TypeName); cfg.suggestable(tn, pkg) {
+				p.ifaces.Set(key, tn)
+			}
+		})
+		for _, tn := range generics {
+			if cfg.suggestable(tn, pkg) {
+				p.generics = append(p.generics, tn)
 			}
-			return name
 		}
-		for iftype, name := range ifs {
-			// only suggest exported interfaces
-			if ast.IsExported(name) {
-				p.ifaces[iftype] = fullName(name)
+		for _, tn := range sealed {
+			if cfg.suggestable(tn, pkg) {
+				p.sealed = append(p.sealed, tn)
 			}
 		}
-		for ftype := range funs {
-			// ignore non-exported func signatures too
-			p.funcSigns[ftype] = true
+	}
+	// imports are added depth-first, and pkg last, so that its own
+	// interfaces take precedence
+	expanded := make(map[*types.Package]int)
+	var addImports func(other *types.Package, depth int)
+	addImports = func(other *types.Package, depth int) {
+		if d, e := expanded[other]; depth <= 0 || (e && d >= depth) {
+			return
+		}
+		expanded[other] = depth
+		for _, imp := range other.Imports() {
+			addTypes(imp, false)
+			addImports(imp, depth-1)
+		}
+	}
+	// the providers that are also close imports are left for later
+	for _, other := range providers {
+		if level, e := levels[other]; !e || level > cfg.ImportDepth {
+			addTypes(other, true)
+		}
+	}
+	addImports(pkg, maxDepth)
+	addTypes(pkg, false)
+}
+
+// providersFor returns the packages searched for interfaces to suggest
+// for pkg because of the scope set by cfg, sorted by path. They are
+// found among all the loaded packages and their imports.
+func (c *Checker) providersFor(pkg *types.Package, cfg *config) []*types.Package {
+	modPath := c.modules[pkg]
+	seen := map[*types.Package]bool{pkg: true}
+	var providers []*types.Package
+	var add func(other *types.Package)
+	add = func(other *types.Package) {
+		if seen[other] {
+			return
+		}
+		seen[other] = true
+		if cfg.provides(other.Path(), modPath) {
+			providers = append(providers, other)
+		}
+		for _, imp := range other.Imports() {
+			add(imp)
 		}
 	}
 	for _, imp := range pkg.Imports() {
-		addTypes(imp, false)
-		for _, imp2 := range imp.Imports() {
-			addTypes(imp2, false)
+		add(imp)
+	}
+	for _, other := range c.providers {
+		add(other)
+	}
+	for _, other := range c.pkgs {
+		if other.Types != nil {
+			add(other.Types)
+		}
+	}
+	sort.Slice(providers, func(i, j int) bool {
+		return providers[i].Path() < providers[j].Path()
+	})
+	return providers
+}
+
+// importable reports whether pkg may import other, which isn't the case
+// for main packages, vendored packages in the standard library, and
+// internal packages outside of their parent's tree. Import cycles are
+// checked separately, see imports.
+func importable(other, pkg *types.Package) bool {
+	return importablePath(other.Path(), other.Name(), pkg.Path())
+}
+
+// importablePath is like importable, given the path and name of the
+// imported package and the path of the importing one.
+func importablePath(p, name, fromPath string) bool {
+	if name == "main" || strings.HasPrefix(p, "vendor/") {
+		return false
+	}
+	i := strings.LastIndex(p, "/internal/")
+	switch {
+	case i >= 0:
+	case strings.HasSuffix(p, "/internal"):
+		i = len(p) - len("/internal")
+	case strings.HasPrefix(p, "internal/") || p == "internal":
+		return false
+	default:
+		return true
+	}
+	parent := p[:i]
+	// external test packages live in the same directory
+	from := strings.TrimSuffix(fromPath, "_test")
+	return from == parent || strings.HasPrefix(from, parent+"/")
+}
+
+// imports reports whether from imports to, directly or indirectly, in
+// which case to can't import from without a cycle. The results are
+// cached in memo, which must only be used for the same to.
+func imports(from, to *types.Package, memo map[*types.Package]bool) bool {
+	if found, e := memo[from]; e {
+		return found
+	}
+	found := false
+	for _, imp := range from.Imports() {
+		if imp.Path() == to.Path() || imports(imp, to, memo) {
+			found = true
+			break
 		}
 	}
-	addTypes(pkg, true)
+	memo[from] = found
+	return found
 }
@@ -4,51 +4,226 @@
 package check // import "mvdan.cc/interfacer/check"
 
 import (
+	"errors"
 	"fmt"
 	"go/ast"
+	"go/build"
 	"go/token"
 	"go/types"
+	"maps"
 	"os"
+	"path/filepath"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
-	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/packages"
 	"golang.org/x/tools/go/ssa"
 	"golang.org/x/tools/go/ssa/ssautil"
-
-	"github.com/kisielk/gotool"
-	"mvdan.cc/lint"
 )
 
-func toDiscard(usage *varUsage) bool {
+func (c *Checker) toDiscard(usage *varUsage) bool {
 	if usage.discard {
 		return true
 	}
+	for param := range usage.passed {
+		if _, e := c.required[param]; !e {
+			// the param it's passed to keeps its type
+			return true
+		}
+	}
 	for to := range usage.assigned {
-		if toDiscard(to) {
+		if c.toDiscard(to) {
 			return true
 		}
 	}
 	return false
 }
 
-func allCalls(usage *varUsage, all, ftypes map[string]string) {
+func (c *Checker) allCalls(usage *varUsage, all, ftypes funcMap) {
 	for fname := range usage.calls {
 		all[fname] = ftypes[fname]
 	}
+	for param := range usage.passed {
+		for fname, sign := range c.required[param] {
+			all[fname] = sign
+		}
+	}
+	for to := range usage.assigned {
+		c.allCalls(to, all, ftypes)
+	}
+}
+
+// allPassed adds the params that usage is passed to into all.
+func allPassed(usage *varUsage, all map[*types.Var]struct{}) {
+	for param := range usage.passed {
+		all[param] = struct{}{}
+	}
 	for to := range usage.assigned {
-		allCalls(to, all, ftypes)
+		allPassed(to, all)
 	}
 }
 
-func (c *Checker) interfaceMatching(param *types.Var, usage *varUsage) (string, string) {
-	if toDiscard(usage) {
-		return "", ""
+// neededBy returns the positions of the params that usage is passed to,
+// which must be narrowed along with it.
+func neededBy(usage *varUsage) []token.Pos {
+	passed := make(map[*types.Var]struct{})
+	allPassed(usage, passed)
+	var needs []token.Pos
+	for p := range passed {
+		needs = append(needs, p.Pos())
 	}
-	ftypes := typeFuncMap(param.Type())
-	called := make(map[string]string, len(usage.calls))
-	allCalls(usage, called, ftypes)
-	s := funcMapString(called)
-	return c.ifaces[s], s
+	return needs
+}
+
+func (c *Checker) interfaceMatching(t types.Type, usage *varUsage, exported bool) *suggestion {
+	if c.toDiscard(usage) {
+		return nil
+	}
+	ftypes := typeFuncMap(t)
+	called := make(funcMap, len(usage.calls))
+	c.allCalls(usage, called, ftypes)
+	if key := called.iface(); key != nil {
+		if tn, _ := c.ifaces.At(key).(*types.TypeName); tn != nil {
+			return &suggestion{tn: tn, funcs: called}
+		}
+	}
+	if sugg := c.factMatching(called); sugg != nil {
+		return sugg
+	}
+	if sugg := c.genericMatching(t, called); sugg != nil {
+		return sugg
+	}
+	sealed := c.sealedMatching(t, called)
+	if sealed != nil && !sealed.superset {
+		return sealed
+	}
+	// sealed interfaces are only implemented by the types in their own
+	// package, so they are suggested even without superset matching, as
+	// long as no other interface is a better match
+	if c.superset || sealed != nil {
+		sugg := c.supersetMatching(t, called)
+		if sugg != nil && (c.superset || sugg.tn == sealed.tn) {
+			return sugg
+		}
+	}
+	if c.synth {
+		return c.synthesize(t, called, exported)
+	}
+	return nil
+}
+
+// supersetMatching returns the smallest known interface whose methods
+// include all the ones in called, and which t implements. Interfaces
+// with unexported methods are considered too, by their exported methods.
+// Ties are broken by the interfaces' full names.
+func (c *Checker) supersetMatching(t types.Type, called funcMap) *suggestion {
+	if len(called) == 0 {
+		return nil
+	}
+	have := typeFuncMap(t)
+	var best *suggestion
+	bestSize := 0
+	consider := func(tn *types.TypeName, funcs funcMap) {
+		iface, _ := tn.Type().Underlying().(*types.Interface)
+		if iface == nil || len(funcs) <= len(called) || !containsFuncs(funcs, called) {
+			return
+		}
+		size := iface.NumMethods()
+		if types.IsInterface(t.Underlying()) && size >= len(have) {
+			// not any narrower
+			return
+		}
+		if !types.Implements(t, iface) {
+			return
+		}
+		if best != nil {
+			if size > bestSize {
+				return
+			}
+			if size == bestSize && fullName(tn) >= fullName(best.tn) {
+				return
+			}
+		}
+		best = &suggestion{tn: tn, funcs: funcs, superset: true}
+		bestSize = size
+	}
+	c.ifaces.Iterate(func(_ types.Type, value any) {
+		tn := value.(*types.TypeName)
+		consider(tn, typeFuncMap(tn.Type()))
+	})
+	for _, tn := range c.sealed {
+		consider(tn, methoderFuncMap(tn.Type().Underlying().(*types.Interface), true))
+	}
+	return best
+}
+
+// sealedMatching returns the smallest known interface with unexported
+// methods which t implements, and whose exported methods include all
+// the ones in called. Such interfaces can't be implemented outside of
+// their package, so they are never exact matches otherwise. Ties are
+// broken by the interfaces' full names.
+func (c *Checker) sealedMatching(t types.Type, called funcMap) *suggestion {
+	if len(called) == 0 {
+		return nil
+	}
+	have := typeFuncMap(t)
+	var best *suggestion
+	bestSize := 0
+	for _, tn := range c.sealed {
+		iface := tn.Type().Underlying().(*types.Interface)
+		size := iface.NumMethods()
+		if types.IsInterface(t.Underlying()) && size >= len(have) {
+			// not any narrower
+			continue
+		}
+		funcs := methoderFuncMap(iface, true)
+		if !containsFuncs(funcs, called) || !types.Implements(t, iface) {
+			continue
+		}
+		superset := len(funcs) > len(called)
+		if best != nil {
+			if superset != best.superset {
+				if superset {
+					// exact matches come first
+					continue
+				}
+			} else if size > bestSize || size == bestSize && fullName(tn) >= fullName(best.tn) {
+				continue
+			}
+		}
+		best = &suggestion{tn: tn, funcs: funcs, superset: superset}
+		bestSize = size
+	}
+	return best
+}
+
+func fullName(tn *types.TypeName) string {
+	return tn.Pkg().Path() + "." + tn.Name()
+}
+
+// suggestion is an interface type proposed for a parameter.
+type suggestion struct {
+	tn *types.TypeName
+
+	// funcs are the methods of the interface.
+	funcs funcMap
+
+	// typ is the instantiated interface type if tn is generic, such as
+	// Getter[string].
+	typ types.Type
+
+	// superset is true if the interface has more methods than the
+	// ones actually used.
+	superset bool
+
+	// synthesized is true if the interface doesn't exist yet, and
+	// would have to be declared in the package.
+	synthesized bool
+
+	// hazards are the ways in which the change could alter the
+	// behavior at run time.
+	hazards []Hazard
 }
 
 type varUsage struct {
@@ -56,6 +231,17 @@ type varUsage struct {
 	discard bool
 
 	assigned map[*varUsage]struct{}
+
+	// passed are the params of funcs and the struct fields in the
+	// same package that the variable is passed to, which keep it
+	// usable only if they can be narrowed too.
+	passed map[*types.Var]struct{}
+
+	// nilCompared is true if the variable is compared with nil.
+	nilCompared bool
+	// hashed is true if the variable is compared with a value that
+	// may be an interface, or used as a map key of interface type.
+	hashed bool
 }
 
 type funcDecl struct {
@@ -63,29 +249,124 @@ type funcDecl struct {
 	ssaFn   *ssa.Function
 }
 
-// CheckArgs checks the packages specified by their import paths in
-// args.
-func CheckArgs(args []string) ([]string, error) {
-	paths := gotool.ImportPaths(args)
-	conf := loader.Config{}
-	conf.AllowErrors = true
-	rest, err := conf.FromArgs(paths, false)
+// Options describes which packages to check and how to load them.
+type Options struct {
+	// Patterns are the packages to check, in any form understood by
+	// "go list", including module-aware patterns.
+	Patterns []string
+
+	// BuildFlags are passed on to the build system, such as
+	// "-tags=foo" or "-mod=vendor".
+	BuildFlags []string
+
+	// Dir is the directory in which to run the build system. If
+	// empty, the current directory is used.
+	Dir string
+
+	// Tests also checks the test files of the packages, including
+	// their external test packages.
+	Tests bool
+
+	// Superset allows suggesting the smallest known interface that
+	// contains all the methods used, when none matches them exactly.
+	Superset bool
+
+	// Synthesize suggests a new interface with the methods used when
+	// no known interface fits them.
+	Synthesize bool
+
+	// IfaceMethods also checks the parameters of the interface methods
+	// declared in the checked packages, merging their usage across all
+	// the implementations in the checked packages.
+	IfaceMethods bool
+
+	// Verify applies each suggestion in memory and type-checks the
+	// affected package and all loaded packages depending on it,
+	// dropping the suggestions that would not compile.
+	Verify bool
+
+	// KeepRejected keeps the suggestions rejected by Verify, with
+	// Issue.Rejected set to the type error that rules them out.
+	KeepRejected bool
+
+	// KeepHazards keeps the suggestions that could change the
+	// behavior at run time, with Issue.Hazards set.
+	KeepHazards bool
+}
+
+// Check loads the packages described by opts and returns the issues
+// found in them, sorted by position.
+func Check(opts Options) ([]Issue, error) {
+	cfg := &packages.Config{
+		// only listed at first, to find their configs
+		Mode:       packages.NeedName | packages.NeedFiles | packages.NeedModule | packages.NeedForTest,
+		BuildFlags: opts.BuildFlags,
+		Dir:        opts.Dir,
+		Tests:      opts.Tests,
+	}
+	listed, err := packages.Load(cfg, opts.Patterns...)
 	if err != nil {
 		return nil, err
 	}
-	if len(rest) > 0 {
-		return nil, fmt.Errorf("unwanted extra args: %v", rest)
+	if err := loadErrors(listed); err != nil {
+		return nil, err
+	}
+	c := &Checker{
+		superset:     opts.Superset,
+		synth:        opts.Synthesize,
+		ifaceMethods: opts.IfaceMethods,
+		keepHazards:  opts.KeepHazards,
+		lazyDeps:     true,
+	}
+	extra, err := c.providerPatterns(listed)
+	if err != nil {
+		return nil, err
 	}
-	lprog, err := conf.Load()
+	// loaded along with the packages to check, so that they share the
+	// same types
+	cfg.Mode = packages.LoadAllSyntax | packages.NeedModule | packages.NeedForTest
+	pkgs, err := packages.Load(cfg, append(opts.Patterns, extra...)...)
 	if err != nil {
 		return nil, err
 	}
-	prog := ssautil.CreateProgram(lprog, 0)
-	prog.Build()
-	c := new(Checker)
-	c.Program(lprog)
+	if len(extra) > 0 {
+		pkgs = c.splitProviders(pkgs, listed)
+	}
+	if err := loadErrors(pkgs); err != nil {
+		return nil, err
+	}
+	if opts.Tests {
+		pkgs = testVariants(pkgs)
+	}
+	// dependencies are only built when needed, see addFastPaths
+	prog, ssaPkgs := ssautil.AllPackages(pkgs, 0)
+	for _, ssaPkg := range ssaPkgs {
+		if ssaPkg != nil {
+			ssaPkg.Build()
+		}
+	}
+	c.Packages(pkgs)
 	c.ProgramSSA(prog)
 	issues, err := c.Check()
+	if err != nil || !opts.Verify {
+		return issues, err
+	}
+	return c.verify(issues, opts.KeepRejected)
+}
+
+// CheckArgs checks the packages specified by the patterns in args, and
+// returns the issues found as lines of text. Build tags set in
+// build.Default are passed on to the build system.
+func CheckArgs(args []string) ([]string, error) {
+	args, rest := splitArgs(args)
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("unwanted extra args: %v", rest)
+	}
+	opts := Options{Patterns: args}
+	if tags := build.Default.BuildTags; len(tags) > 0 {
+		opts.BuildFlags = []string{"-tags=" + strings.Join(tags, ",")}
+	}
+	issues, err := Check(opts)
 	if err != nil {
 		return nil, err
 	}
@@ -95,52 +376,199 @@ func CheckArgs(args []string) ([]string, error) {
 	}
 	lines := make([]string, len(issues))
 	for i, issue := range issues {
-		fpos := prog.Fset.Position(issue.Pos()).String()
-		if strings.HasPrefix(fpos, wd) {
-			fpos = fpos[len(wd)+1:]
-		}
-		lines[i] = fmt.Sprintf("%s: %s", fpos, issue.Message())
+		lines[i] = issue.Format(wd)
 	}
 	return lines, nil
 }
 
+// splitArgs separates the package patterns from any arguments following
+// a "--" separator.
+func splitArgs(args []string) (patterns, rest []string) {
+	for i, arg := range args {
+		if arg == "--" {
+			return args[:i], args[i+1:]
+		}
+	}
+	return args, nil
+}
+
+// loadErrors returns an error listing the errors found while loading
+// pkgs, such as patterns matching no packages or type errors, if any.
+func loadErrors(pkgs []*packages.Package) error {
+	var msgs []string
+	for _, pkg := range pkgs {
+		for _, err := range pkg.Errors {
+			msgs = append(msgs, err.Error())
+		}
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(msgs, "\n"))
+}
+
 type Checker struct {
-	lprog *loader.Program
-	prog  *ssa.Program
+	pkgs []*packages.Package
+	prog *ssa.Program
+	fset *token.FileSet
 
 	pkgTypes
-	*loader.PackageInfo
+	pkg *types.Package
+	*types.Info
+	files []*ast.File
 
 	funcs []*funcDecl
 
 	ssaByPos map[token.Pos]*ssa.Function
 
+	superset     bool
+	synth        bool
+	ifaceMethods bool
+	keepHazards  bool
+
+	// lazyDeps is true if the packages in prog other than the ones
+	// being checked have syntax, but are only built on demand.
+	lazyDeps bool
+
+	synthesized map[*types.Package][]*types.Named
+	synthNames  map[string]bool
+
 	discardFuncs map[*types.Signature]struct{}
+	implemented  map[*types.Func]bool
+	impls        map[*types.Func]*implDecl
+	called       map[*ast.Ident]bool
 
 	vars map[*types.Var]*varUsage
+
+	// params are those of the funcs declared in the package
+	params map[*types.Var]bool
+
+	// fields are the struct fields in the package whose uses are
+	// tracked, mapped to the struct types declaring them
+	fields map[*types.Var]*types.TypeName
+
+	// results are those of the func whose body is being visited
+	results *types.Tuple
+
+	// passedTo are the params that variables are passed to in the
+	// package, and required the methods of the types they are
+	// assumed to be narrowed to. A missing entry means that the
+	// param keeps its type.
+	passedTo map[*types.Var]bool
+	required map[*types.Var]funcMap
+
+	// directives are the ones suppressing issues in all the packages
+	// checked so far
+	directives []*directive
+
+	// cfg is the config for the package being checked, as found in
+	// pkgConfigs. configs caches the configs by directory.
+	cfg        *config
+	pkgConfigs map[*types.Package]*config
+	configs    map[string]*config
+
+	// providers are the packages loaded only to search them for
+	// interfaces, and modules are the module paths of the packages
+	providers []*types.Package
+	modules   map[*types.Package]string
+
+	// factIfaces are the interfaces known only from the facts of the
+	// dependencies, when running as an analyzer
+	factIfaces map[string]*types.TypeName
 }
 
-var (
-	_ lint.Checker = (*Checker)(nil)
-	_ lint.WithSSA = (*Checker)(nil)
-)
+// providerPatterns returns the patterns of the packages to load only to
+// search them for interfaces, as set by the configs of pkgs.
+func (c *Checker) providerPatterns(pkgs []*packages.Package) ([]string, error) {
+	var patterns []string
+	seen := make(map[string]bool)
+	for _, pkg := range pkgs {
+		cfg, err := c.loadConfig(fileDir(pkg.GoFiles))
+		if err != nil {
+			return nil, err
+		}
+		modPath := ""
+		if pkg.Module != nil {
+			modPath = pkg.Module.Path
+		}
+		for _, pattern := range cfg.providerPatterns(modPath) {
+			if !seen[pattern] {
+				seen[pattern] = true
+				patterns = append(patterns, pattern)
+			}
+		}
+	}
+	return patterns, nil
+}
+
+// splitProviders returns the packages in all that were listed, which are
+// the ones to check. The others were only loaded to search them for
+// interfaces, and are kept in c.providers.
+func (c *Checker) splitProviders(all, listed []*packages.Package) []*packages.Package {
+	wanted := make(map[string]bool)
+	for _, pkg := range listed {
+		wanted[pkg.ID] = true
+	}
+	var checked []*packages.Package
+	for _, pkg := range all {
+		switch {
+		case wanted[pkg.ID]:
+			checked = append(checked, pkg)
+		case pkg.Types != nil && pkg.ForTest == "" && !strings.HasSuffix(pkg.PkgPath, ".test"):
+			c.providers = append(c.providers, pkg.Types)
+		}
+	}
+	return checked
+}
+
+// testVariants drops the packages that are also loaded as part of a
+// test variant, as the variant has all of their files plus the tests,
+// as well as the generated test main packages. Otherwise, each func
+// would be checked once per variant.
+func testVariants(pkgs []*packages.Package) []*packages.Package {
+	hasVariant := make(map[string]bool)
+	for _, pkg := range pkgs {
+		if pkg.ForTest != "" && pkg.PkgPath == pkg.ForTest {
+			hasVariant[pkg.PkgPath] = true
+		}
+	}
+	var kept []*packages.Package
+	for _, pkg := range pkgs {
+		switch {
+		case pkg.ForTest == "" && hasVariant[pkg.PkgPath]:
+		case pkg.Name == "main" && strings.HasSuffix(pkg.PkgPath, ".test"):
+		default:
+			kept = append(kept, pkg)
+		}
+	}
+	return kept
+}
 
-func (c *Checker) Program(lprog *loader.Program) {
-	c.lprog = lprog
+// Packages sets the initial packages to check. They must have been
+// loaded with typed syntax, such as with packages.LoadAllSyntax, and
+// packages.NeedModule is needed for the module-interfaces setting.
+func (c *Checker) Packages(pkgs []*packages.Package) {
+	c.pkgs = pkgs
 }
 
 func (c *Checker) ProgramSSA(prog *ssa.Program) {
 	c.prog = prog
+	c.fset = prog.Fset
 }
 
-func (c *Checker) Check() ([]lint.Issue, error) {
-	var total []lint.Issue
+func (c *Checker) Check() ([]Issue, error) {
+	var total []Issue
 	c.ssaByPos = make(map[token.Pos]*ssa.Function)
+	c.impls = make(map[*types.Func]*implDecl)
 	wantPkg := make(map[*types.Package]bool)
-	for _, pinfo := range c.lprog.InitialPackages() {
-		wantPkg[pinfo.Pkg] = true
+	for _, pkg := range c.pkgs {
+		if pkg.Types != nil {
+			wantPkg[pkg.Types] = true
+		}
 	}
-	for fn := range ssautil.AllFunctions(c.prog) {
+	allFns := ssautil.AllFunctions(c.prog)
+	c.findImplemented(allFns)
+	for fn := range allFns {
 		if fn.Pkg == nil { // builtin?
 			continue
 		}
@@ -152,44 +580,146 @@ func (c *Checker) Check() ([]lint.Issue, error) {
 		}
 		c.ssaByPos[fn.Pos()] = fn
 	}
-	for _, pinfo := range c.lprog.InitialPackages() {
-		pkg := pinfo.Pkg
-		c.getTypes(pkg)
-		c.PackageInfo = c.lprog.AllPackages[pkg]
-		total = append(total, c.checkPkg()...)
+	c.pkgConfigs = make(map[*types.Package]*config)
+	for _, pkg := range c.pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		cfg, err := c.loadConfig(fileDir(pkg.GoFiles))
+		if err != nil {
+			return nil, err
+		}
+		c.pkgConfigs[pkg.Types] = cfg
+		if pkg.Module != nil {
+			if c.modules == nil {
+				c.modules = make(map[*types.Package]string)
+			}
+			c.modules[pkg.Types] = pkg.Module.Path
+		}
+	}
+	for _, pkg := range c.pkgs {
+		if pkg.Types == nil || pkg.TypesInfo == nil {
+			// could not be loaded
+			continue
+		}
+		if c.pkgConfigs[pkg.Types].excluded(pkg.PkgPath) {
+			continue
+		}
+		total = append(total, c.checkPkg(pkg.Types, pkg.TypesInfo, pkg.Syntax)...)
+	}
+	if c.ifaceMethods {
+		total = append(total, c.suppress(c.ifaceMethodIssues(), true)...)
 	}
+	total = append(total, c.unusedDirectives()...)
+	sortIssues(total)
 	return total, nil
 }
 
-func (c *Checker) checkPkg() []lint.Issue {
+// fileDir returns the directory of the first of files, or an empty
+// string if there are none.
+func fileDir(files []string) string {
+	if len(files) == 0 {
+		return ""
+	}
+	return filepath.Dir(files[0])
+}
+
+func (c *Checker) checkPkg(pkg *types.Package, info *types.Info, files []*ast.File) []Issue {
+	if c.cfg = c.pkgConfigs[pkg]; c.cfg == nil {
+		c.cfg = defaultConfig()
+	}
+	c.getTypes(pkg, c.cfg, c.providersFor(pkg, c.cfg))
+	c.pkg = pkg
+	c.Info = info
+	c.files = files
+	c.directives = append(c.directives, c.packageDirectives()...)
+	if c.synthesized == nil {
+		c.synthesized = make(map[*types.Package][]*types.Named)
+		c.synthNames = make(map[string]bool)
+	}
 	c.discardFuncs = make(map[*types.Signature]struct{})
+	c.called = make(map[*ast.Ident]bool)
 	c.vars = make(map[*types.Var]*varUsage)
+	c.params = make(map[*types.Var]bool)
+	c.fields = nil
+	if !c.testsMissing(pkg) {
+		c.fields = structFields(pkg)
+	}
+	c.passedTo = make(map[*types.Var]bool)
+	if c.required == nil {
+		// kept for the interface methods checked later
+		c.required = make(map[*types.Var]funcMap)
+	}
 	c.funcs = c.funcs[:0]
-	findFuncs := func(node ast.Node) bool {
-		decl, ok := node.(*ast.FuncDecl)
-		if !ok {
-			return true
+	for _, f := range c.files {
+		for _, decl := range f.Decls {
+			switch x := decl.(type) {
+			case *ast.GenDecl:
+				// funcs may be used as values in global vars
+				ast.Walk(c, x)
+			case *ast.FuncDecl:
+				c.checkFuncDecl(x)
+			}
 		}
-		ssaFn := c.ssaByPos[decl.Name.Pos()]
-		if ssaFn == nil {
-			return true
+	}
+	return c.packageIssues()
+}
+
+func (c *Checker) checkFuncDecl(decl *ast.FuncDecl) {
+	if decl.Body == nil {
+		return
+	}
+	sign := c.Defs[decl.Name].Type().(*types.Signature)
+	c.results = sign.Results()
+	params := sign.Params()
+	for i := 0; i < params.Len(); i++ {
+		c.params[params.At(i)] = true
+	}
+	// always walked, as struct fields may be used anywhere
+	ast.Walk(c, decl.Body)
+	ssaFn := c.ssaByPos[decl.Name.Pos()]
+	if ssaFn == nil {
+		return
+	}
+	fd := &funcDecl{
+		astDecl: decl,
+		ssaFn:   ssaFn,
+	}
+	if fn, ok := ssaFn.Object().(*types.Func); ok && c.mayImplement(fn) {
+		// implements interface
+		if c.ifaceMethods {
+			c.impls[fn] = &implDecl{
+				fd:           fd,
+				vars:         c.vars,
+				discardFuncs: c.discardFuncs,
+			}
 		}
-		fd := &funcDecl{
-			astDecl: decl,
-			ssaFn:   ssaFn,
+		return
+	}
+	if c.isTestFunc(decl) {
+		// called by "go test", which requires its signature
+		return
+	}
+	c.funcs = append(c.funcs, fd)
+}
+
+// isTestFunc reports whether decl is a test, benchmark or fuzz test
+// declared in a _test.go file.
+func (c *Checker) isTestFunc(decl *ast.FuncDecl) bool {
+	if decl.Recv != nil || !strings.HasSuffix(c.fset.Position(decl.Pos()).Filename, "_test.go") {
+		return false
+	}
+	for _, prefix := range [...]string{"Test", "Benchmark", "Fuzz"} {
+		name := strings.TrimPrefix(decl.Name.Name, prefix)
+		if name == decl.Name.Name {
+			continue
 		}
-		if c.funcSigns[signString(fd.ssaFn.Signature)] {
-			// implements interface
+		// TestFoo and Test_foo, but not Testfoo
+		if r, _ := utf8.DecodeRuneInString(name); name == "" || !unicode.IsLower(r) {
 			return true
 		}
-		c.funcs = append(c.funcs, fd)
-		ast.Walk(c, decl.Body)
-		return true
 	}
-	for _, f := range c.Files {
-		ast.Inspect(f, findFuncs)
-	}
-	return c.packageIssues()
+	return false
 }
 
 func paramVarAndType(sign *types.Signature, i int) (*types.Var, types.Type) {
@@ -213,14 +743,16 @@ func paramVarAndType(sign *types.Signature, i int) (*types.Var, types.Type) {
 }
 
 func (c *Checker) varUsage(e ast.Expr) *varUsage {
-	id, ok := e.(*ast.Ident)
-	if !ok {
-		return nil
-	}
-	param, ok := c.ObjectOf(id).(*types.Var)
-	if !ok {
-		// not a variable
-		return nil
+	param := c.trackedField(e)
+	if param == nil {
+		id, ok := ast.Unparen(e).(*ast.Ident)
+		if !ok {
+			return nil
+		}
+		if param, ok = c.ObjectOf(id).(*types.Var); !ok || param.IsField() {
+			// not a variable
+			return nil
+		}
 	}
 	if usage, e := c.vars[param]; e {
 		return usage
@@ -231,6 +763,7 @@ func (c *Checker) varUsage(e ast.Expr) *varUsage {
 	usage := &varUsage{
 		calls:    make(map[string]struct{}),
 		assigned: make(map[*varUsage]struct{}),
+		passed:   make(map[*types.Var]struct{}),
 	}
 	c.vars[param] = usage
 	return usage
@@ -240,6 +773,10 @@ func (c *Checker) addUsed(e ast.Expr, as types.Type) {
 	if as == nil {
 		return
 	}
+	if tp, ok := as.(*types.TypeParam); ok && types.Identical(tp, c.TypeOf(e)) {
+		// kept as the same type param, see typeParamIssues
+		return
+	}
 	if usage := c.varUsage(e); usage != nil {
 		// using variable
 		iface, ok := as.Underlying().(*types.Interface)
@@ -274,16 +811,70 @@ func (c *Checker) discard(e ast.Expr) {
 }
 
 func (c *Checker) comparedWith(e, with ast.Expr) {
+	usage := c.varUsage(e)
+	if usage == nil {
+		return
+	}
 	if _, ok := with.(*ast.BasicLit); ok {
-		c.discard(e)
+		usage.discard = true
+		return
+	}
+	if c.Types[with].IsNil() {
+		usage.nilCompared = true
+		return
+	}
+	if types.IsInterface(c.TypeOf(with)) {
+		usage.hashed = true
+	} else if id, ok := ast.Unparen(with).(*ast.Ident); ok {
+		if vr, ok := c.ObjectOf(id).(*types.Var); ok && c.params[vr] {
+			// may become an interface too
+			usage.hashed = true
+		}
 	}
 }
 
+// usedAsKey records e being used as a key in a map with the given key
+// type.
+func (c *Checker) usedAsKey(e ast.Expr, key types.Type) {
+	c.addUsed(e, key)
+	if usage := c.varUsage(e); usage != nil && types.IsInterface(key) {
+		usage.hashed = true
+	}
+}
+
+// Visit records how the variables in the visited code are used. Each
+// use of a variable is either a use as some type, which keeps only the
+// methods of that type if it's an interface, a discard, which keeps the
+// variable's type as is, or an assignment to another variable, whose
+// uses then count too.
 func (c *Checker) Visit(node ast.Node) ast.Visitor {
 	switch x := node.(type) {
+	case *ast.Ident:
+		if fn, ok := c.Uses[x].(*types.Func); ok && !c.called[x] {
+			// func used as a value
+			c.discardFuncs[fn.Type().(*types.Signature)] = struct{}{}
+		}
+	case *ast.FuncLit:
+		// returns within refer to the func literal
+		results := c.results
+		c.results = c.TypeOf(x).(*types.Signature).Results()
+		ast.Walk(c, x.Body)
+		c.results = results
+		return nil
 	case *ast.SelectorExpr:
-		if _, ok := c.TypeOf(x.Sel).(*types.Signature); !ok {
+		sel := c.Selections[x]
+		if sel == nil {
+			// qualified identifier
+			break
+		}
+		switch sel.Kind() {
+		case types.FieldVal:
 			c.discard(x.X)
+		case types.MethodVal:
+			// called directly or used as a method value
+			if usage := c.varUsage(x.X); usage != nil {
+				usage.calls[x.Sel.Name] = struct{}{}
+			}
 		}
 	case *ast.StarExpr:
 		c.discard(x.X)
@@ -291,6 +882,16 @@ func (c *Checker) Visit(node ast.Node) ast.Visitor {
 		c.discard(x.X)
 	case *ast.IndexExpr:
 		c.discard(x.X)
+		if m, ok := c.TypeOf(x.X).Underlying().(*types.Map); ok {
+			c.usedAsKey(x.Index, m.Key())
+		} else {
+			c.discard(x.Index)
+		}
+	case *ast.SliceExpr:
+		c.discard(x.X)
+	case *ast.TypeAssertExpr:
+		// depends on the dynamic type, also in type switches
+		c.discard(x.X)
 	case *ast.IncDecStmt:
 		c.discard(x.X)
 	case *ast.BinaryExpr:
@@ -302,31 +903,84 @@ func (c *Checker) Visit(node ast.Node) ast.Visitor {
 			c.discard(x.X)
 			c.discard(x.Y)
 		}
+	case *ast.SwitchStmt:
+		if x.Tag == nil {
+			break
+		}
+		for _, stmt := range x.Body.List {
+			for _, e := range stmt.(*ast.CaseClause).List {
+				c.comparedWith(x.Tag, e)
+				c.comparedWith(e, x.Tag)
+			}
+		}
 	case *ast.ValueSpec:
-		for _, val := range x.Values {
-			c.addUsed(val, c.TypeOf(x.Type))
+		for i, val := range x.Values {
+			if x.Type != nil {
+				c.addUsed(val, c.TypeOf(x.Type))
+			} else if len(x.Names) == len(x.Values) {
+				c.addAssign(x.Names[i], val)
+			}
 		}
 	case *ast.AssignStmt:
 		for i, val := range x.Rhs {
 			left := x.Lhs[i]
-			if x.Tok == token.ASSIGN {
+			switch x.Tok {
+			case token.ASSIGN:
+				if field := c.trackedField(left); field != nil && c.passTo(val, field) {
+					continue
+				}
 				c.addUsed(val, c.TypeOf(left))
+			case token.DEFINE:
+			default:
+				// op-assign, such as +=
+				c.discard(left)
+				c.discard(val)
+				continue
 			}
 			c.addAssign(left, val)
 		}
+	case *ast.ReturnStmt:
+		if c.results == nil || len(x.Results) != c.results.Len() {
+			// bare return, or returning a multi-value call
+			break
+		}
+		for i, e := range x.Results {
+			c.addUsed(e, c.results.At(i).Type())
+		}
+	case *ast.SendStmt:
+		if ch, ok := c.TypeOf(x.Chan).Underlying().(*types.Chan); ok {
+			c.addUsed(x.Value, ch.Elem())
+		}
+	case *ast.RangeStmt:
+		c.discard(x.X)
 	case *ast.CompositeLit:
+		t := c.TypeOf(x)
 		for i, e := range x.Elts {
-			switch y := e.(type) {
-			case *ast.KeyValueExpr:
-				c.addUsed(y.Key, c.TypeOf(y.Value))
-				c.addUsed(y.Value, c.TypeOf(y.Key))
-			case *ast.Ident:
-				c.addUsed(y, compositeIdentType(c.TypeOf(x), i))
+			if kv, ok := e.(*ast.KeyValueExpr); ok {
+				if m, ok := t.Underlying().(*types.Map); ok {
+					c.usedAsKey(kv.Key, m.Key())
+					c.addUsed(kv.Value, m.Elem())
+				} else if field := c.compositeField(t, kv.Key, -1); field == nil || !c.passTo(kv.Value, field) {
+					c.addUsed(kv.Value, compositeKeyType(t, kv.Key))
+				}
+				continue
+			}
+			if field := c.compositeField(t, nil, i); field != nil && c.passTo(e, field) {
+				continue
 			}
+			c.addUsed(e, compositeIdentType(t, i))
 		}
 	case *ast.CallExpr:
+		if id := calleeIdent(x.Fun); id != nil {
+			c.called[id] = true
+		}
 		switch y := c.TypeOf(x.Fun).Underlying().(type) {
 		case *types.Signature:
+			if m, ok := c.deleteMap(x); ok {
+				c.usedAsKey(x.Args[1], m.Key())
+				c.discard(x.Args[0])
+				break
+			}
 			c.onMethodCall(x, y)
 		default:
 			// type conversion
@@ -338,10 +992,106 @@ func (c *Checker) Visit(node ast.Node) ast.Visitor {
 	return c
 }
 
+// deleteMap returns the type of the map that ce deletes from, if it's a
+// call to the delete builtin.
+func (c *Checker) deleteMap(ce *ast.CallExpr) (*types.Map, bool) {
+	id, ok := ast.Unparen(ce.Fun).(*ast.Ident)
+	if !ok || len(ce.Args) != 2 {
+		return nil, false
+	}
+	if b, ok := c.Uses[id].(*types.Builtin); !ok || b.Name() != "delete" {
+		return nil, false
+	}
+	m, ok := c.TypeOf(ce.Args[0]).Underlying().(*types.Map)
+	return m, ok
+}
+
+// addPassed records e being passed as the i-th argument to a func
+// declared in the package being checked, if the parameter has the same
+// concrete type. Whether e can be narrowed then depends on whether that
+// parameter can be narrowed too.
+func (c *Checker) addPassed(ce *ast.CallExpr, i int, e ast.Expr) bool {
+	id := calleeIdent(ce.Fun)
+	if id == nil {
+		return false
+	}
+	if sel, ok := ast.Unparen(ce.Fun).(*ast.SelectorExpr); ok {
+		if s := c.Selections[sel]; s != nil && s.Kind() == types.MethodExpr {
+			// the receiver is the first argument
+			return false
+		}
+	}
+	fn, ok := c.Uses[id].(*types.Func)
+	if !ok || fn.Pkg() != c.pkg || fn.Origin() != fn {
+		return false
+	}
+	sign := fn.Type().(*types.Signature)
+	if sign.TypeParams().Len() > 0 || sign.RecvTypeParams().Len() > 0 {
+		return false
+	}
+	params := sign.Params()
+	if i >= params.Len() || (sign.Variadic() && i == params.Len()-1) {
+		return false
+	}
+	return c.passTo(e, params.At(i))
+}
+
+// passTo records e being passed to v, a parameter or a tracked struct
+// field, if both have the same concrete type.
+func (c *Checker) passTo(e ast.Expr, v *types.Var) bool {
+	if types.IsInterface(v.Type()) || !types.Identical(v.Type(), c.TypeOf(e)) {
+		return false
+	}
+	usage := c.varUsage(e)
+	if usage == nil {
+		return false
+	}
+	usage.passed[v] = struct{}{}
+	c.passedTo[v] = true
+	return true
+}
+
+// calleeIdent returns the identifier naming the func or method called
+// via fun, if any.
+func calleeIdent(fun ast.Expr) *ast.Ident {
+	switch x := ast.Unparen(fun).(type) {
+	case *ast.Ident:
+		return x
+	case *ast.SelectorExpr:
+		return x.Sel
+	case *ast.IndexExpr:
+		// explicit instantiation of a generic func
+		return calleeIdent(x.X)
+	case *ast.IndexListExpr:
+		return calleeIdent(x.X)
+	}
+	return nil
+}
+
+// compositeKeyType returns the type of the element with the given key in
+// a composite literal of type t, which is not a map.
+func compositeKeyType(t types.Type, key ast.Expr) types.Type {
+	switch x := t.Underlying().(type) {
+	case *types.Struct:
+		id, ok := key.(*ast.Ident)
+		if !ok {
+			return nil
+		}
+		for i := 0; i < x.NumFields(); i++ {
+			if f := x.Field(i); f.Name() == id.Name {
+				return f.Type()
+			}
+		}
+	case *types.Array:
+		return x.Elem()
+	case *types.Slice:
+		return x.Elem()
+	}
+	return nil
+}
+
 func compositeIdentType(t types.Type, i int) types.Type {
-	switch x := t.(type) {
-	case *types.Named:
-		return compositeIdentType(x.Underlying(), i)
+	switch x := t.Underlying().(type) {
 	case *types.Struct:
 		return x.Field(i).Type()
 	case *types.Array:
@@ -355,6 +1105,10 @@ func compositeIdentType(t types.Type, i int) types.Type {
 func (c *Checker) onMethodCall(ce *ast.CallExpr, sign *types.Signature) {
 	for i, e := range ce.Args {
 		paramObj, t := paramVarAndType(sign, i)
+		if ce.Ellipsis.IsValid() && i == len(ce.Args)-1 {
+			// the slice itself is passed
+			t = sign.Params().At(sign.Params().Len() - 1).Type()
+		}
 		// Don't if this is a parameter being re-used as itself
 		// in a recursive call
 		if id, ok := e.(*ast.Ident); ok {
@@ -362,15 +1116,21 @@ func (c *Checker) onMethodCall(ce *ast.CallExpr, sign *types.Signature) {
 				continue
 			}
 		}
+		if c.addPassed(ce, i, e) {
+			continue
+		}
 		c.addUsed(e, t)
 	}
-	sel, ok := ce.Fun.(*ast.SelectorExpr)
-	if !ok {
+	sel, ok := ast.Unparen(ce.Fun).(*ast.SelectorExpr)
+	if !ok || len(ce.Args) == 0 {
 		return
 	}
-	// receiver func call on the left side
-	if usage := c.varUsage(sel.X); usage != nil {
-		usage.calls[sel.Sel.Name] = struct{}{}
+	if s := c.Selections[sel]; s != nil && s.Kind() == types.MethodExpr {
+		// receiver passed to a method expression, which must
+		// still be assignable to the receiver type as above
+		if usage := c.varUsage(ce.Args[0]); usage != nil {
+			usage.calls[sel.Sel.Name] = struct{}{}
+		}
 	}
 }
 
@@ -389,8 +1149,84 @@ func (fd *funcDecl) paramGroups() [][]*types.Var {
 	return groups
 }
 
-func (c *Checker) packageIssues() []lint.Issue {
-	var issues []lint.Issue
+// packageIssues returns the issues in the package's funcs and struct
+// fields. Since a variable passed to another func or stored in a field
+// in the package may only be narrowed if that is narrowed too, the
+// suggestions are recomputed until they stop changing, starting with
+// the assumption that all those params can be narrowed to the methods
+// called on them.
+func (c *Checker) packageIssues() []Issue {
+	c.seedRequired()
+	synthesized, synthNames := maps.Clone(c.synthesized), maps.Clone(c.synthNames)
+	for {
+		changed := false
+		narrowed := make(map[*types.Var]bool)
+		for _, issue := range c.suppress(append(c.funcIssues(), c.fieldIssues()...), false) {
+			param := issue.Param
+			if !c.passedTo[param] {
+				continue
+			}
+			narrowed[param] = true
+			have, e := c.required[param]
+			if !e {
+				// once kept, always kept
+				continue
+			}
+			for fname, sign := range typeFuncMap(issue.NewType) {
+				if _, e := have[fname]; !e {
+					if have == nil {
+						have = make(funcMap)
+					}
+					have[fname] = sign
+					changed = true
+				}
+			}
+			c.required[param] = have
+		}
+		for param := range c.passedTo {
+			if _, e := c.required[param]; e && !narrowed[param] {
+				delete(c.required, param)
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	// don't keep the interfaces synthesized along the way
+	c.synthesized, c.synthNames = synthesized, synthNames
+	issues := c.suppress(append(c.funcIssues(), c.fieldIssues()...), true)
+	c.addFastPaths(issues)
+	return issues
+}
+
+// seedRequired sets the methods required by each param that variables
+// are passed to as all the methods called on it, including those called
+// via the params it is passed to in turn.
+func (c *Checker) seedRequired() {
+	for param := range c.passedTo {
+		c.required[param] = nil
+	}
+	for changed := true; changed; {
+		changed = false
+		for param := range c.passedTo {
+			usage := c.vars[param]
+			if usage == nil {
+				continue
+			}
+			have := c.required[param]
+			all := make(funcMap, len(have))
+			c.allCalls(usage, all, typeFuncMap(param.Type()))
+			if len(all) > len(have) {
+				c.required[param] = all
+				changed = true
+			}
+		}
+	}
+}
+
+func (c *Checker) funcIssues() []Issue {
+	var issues []Issue
 	for _, fd := range c.funcs {
 		if _, e := c.discardFuncs[fd.ssaFn.Signature]; e {
 			continue
@@ -398,33 +1234,26 @@ func (c *Checker) packageIssues() []lint.Issue {
 		for _, group := range fd.paramGroups() {
 			issues = append(issues, c.groupIssues(fd, group)...)
 		}
+		issues = append(issues, c.typeParamIssues(fd)...)
 	}
 	return issues
 }
 
-type Issue struct {
-	pos token.Pos
-	msg string
-}
-
-func (i Issue) Pos() token.Pos  { return i.pos }
-func (i Issue) Message() string { return i.msg }
-
-func (c *Checker) groupIssues(fd *funcDecl, group []*types.Var) []lint.Issue {
-	var issues []lint.Issue
+func (c *Checker) groupIssues(fd *funcDecl, group []*types.Var) []Issue {
+	var issues []Issue
 	for _, param := range group {
 		usage := c.vars[param]
 		if usage == nil {
 			return nil
 		}
 		newType := c.paramNewType(fd.astDecl.Name.Name, param, usage)
-		if newType == "" {
+		if newType == nil {
 			return nil
 		}
-		issues = append(issues, Issue{
-			pos: param.Pos(),
-			msg: fmt.Sprintf("%s can be %s", param.Name(), newType),
-		})
+		issue := c.newIssue(param, newType)
+		issue.Func, _ = fd.ssaFn.Object().(*types.Func)
+		issue.needs = neededBy(usage)
+		issues = append(issues, issue)
 	}
 	return issues
 }
@@ -437,26 +1266,80 @@ func willAddAllocation(t types.Type) bool {
 	return true
 }
 
-func (c *Checker) paramNewType(funcName string, param *types.Var, usage *varUsage) string {
+func (c *Checker) paramNewType(funcName string, param *types.Var, usage *varUsage) *suggestion {
 	t := param.Type()
-	if !ast.IsExported(funcName) && willAddAllocation(t) {
-		return ""
+	if _, ok := t.(*types.TypeParam); ok {
+		// see typeParamIssues
+		return nil
+	}
+	if c.cfg.SkipUnexportedByValue && !ast.IsExported(funcName) && willAddAllocation(t) {
+		return nil
 	}
-	if named := typeNamed(t); named != nil {
+	if named := typeNamed(t); named != nil && c.cfg.MentionName {
 		tname := named.Obj().Name()
 		vname := param.Name()
 		if mentionsName(funcName, tname) || mentionsName(funcName, vname) {
-			return ""
+			return nil
 		}
 	}
-	ifname, iftype := c.interfaceMatching(param, usage)
-	if ifname == "" {
-		return ""
+	return c.newType(t, usage, ast.IsExported(funcName))
+}
+
+// newType returns the interface type suggested for a variable of type t
+// given its usage, if any.
+func (c *Checker) newType(t types.Type, usage *varUsage, exported bool) *suggestion {
+	if c.ignoredType(t) {
+		return nil
+	}
+	sugg := c.interfaceMatching(t, usage, exported)
+	if sugg == nil {
+		return nil
 	}
 	if types.IsInterface(t.Underlying()) {
-		if have := funcMapString(typeFuncMap(t)); have == iftype {
-			return ""
+		if sameFuncs(typeFuncMap(t), sugg.funcs) {
+			return nil
+		}
+		// any hazards were already there
+		return sugg
+	}
+	sugg.hazards = c.hazards(usage)
+	if len(sugg.hazards) > 0 && !c.keepHazards {
+		return nil
+	}
+	return sugg
+}
+
+// hazards returns the ways in which changing the concrete type of a
+// variable to an interface could alter its behavior, given its usage.
+// A nil pointer compared with nil would no longer be equal to it once
+// wrapped in an interface, and comparing interfaces or using them as
+// map keys panics if their dynamic type isn't comparable.
+func (c *Checker) hazards(usage *varUsage) []Hazard {
+	seen := make(map[*varUsage]bool)
+	var nilCompared, hashed bool
+	var walk func(usage *varUsage)
+	walk = func(usage *varUsage) {
+		if usage == nil || seen[usage] {
+			return
+		}
+		seen[usage] = true
+		nilCompared = nilCompared || usage.nilCompared
+		hashed = hashed || usage.hashed
+		for to := range usage.assigned {
+			walk(to)
+		}
+		for param := range usage.passed {
+			// nil checks further down the call chain
+			walk(c.vars[param])
 		}
 	}
-	return ifname
+	walk(usage)
+	var hazards []Hazard
+	if nilCompared {
+		hazards = append(hazards, HazardTypedNil)
+	}
+	if hashed {
+		hazards = append(hazards, HazardIncomparable)
+	}
+	return hazards
 }
@@ -0,0 +1,326 @@
+// Copyright (c) 2015, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package check
+
+import (
+	"errors"
+	"fmt"
+	"go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// configName is the name of the configuration files, which apply to the
+// packages in their directory and in all the directories below it.
+const configName = ".interfacer.toml"
+
+// config tweaks which suggestions are made for a package. It is read
+// from the configuration files found by walking up from the package's
+// directory, with the settings in the nearest ones taking precedence.
+type config struct {
+	// IgnoreInterfaces are the interfaces never suggested, such as
+	// "io.ReadWriteCloser".
+	IgnoreInterfaces []string
+
+	// IgnoreTypes are the types never replaced by an interface, such
+	// as "*database/sql.Tx". If one of them is an interface, all the
+	// types implementing it are kept too.
+	IgnoreTypes []string
+
+	// ExcludePackages are the packages not checked, as import paths
+	// or patterns such as "example.com/gen/...".
+	ExcludePackages []string
+
+	// ExportedOnly only suggests exported interfaces. If false, the
+	// unexported interfaces of the package itself are suggested too.
+	ExportedOnly bool
+
+	// ImportDepth is how many levels of imports are searched for
+	// interfaces to suggest.
+	ImportDepth int
+
+	// ModuleInterfaces also searches all the packages in the module of
+	// the package being checked, imported or not.
+	ModuleInterfaces bool
+
+	// StdInterfaces also searches all the packages in the standard
+	// library, imported or not.
+	StdInterfaces bool
+
+	// InterfacePackages are more packages to search, as import paths
+	// or patterns such as "example.com/ifaces/...".
+	InterfacePackages []string
+
+	// SkipUnexportedByValue skips the parameters of unexported funcs
+	// that aren't pointers or interfaces, as suggesting an interface
+	// would add allocations.
+	SkipUnexportedByValue bool
+
+	// MentionName skips the parameters whose type is mentioned in the
+	// name of their func, such as ProcessFile(f *os.File).
+	MentionName bool
+}
+
+func defaultConfig() *config {
+	return &config{
+		ExportedOnly:          true,
+		ImportDepth:           2,
+		SkipUnexportedByValue: true,
+		MentionName:           true,
+	}
+}
+
+// loadConfig returns the config for the packages in dir, reading the
+// configuration files from the outermost to the innermost.
+func (c *Checker) loadConfig(dir string) (*config, error) {
+	if cfg := c.configs[dir]; cfg != nil {
+		return cfg, nil
+	}
+	var paths []string
+	if dir != "" {
+		for d := dir; ; d = filepath.Dir(d) {
+			p := filepath.Join(d, configName)
+			if _, err := os.Stat(p); err == nil {
+				paths = append(paths, p)
+			}
+			if filepath.Dir(d) == d {
+				break
+			}
+		}
+	}
+	cfg := defaultConfig()
+	for i := len(paths) - 1; i >= 0; i-- {
+		if err := cfg.decodeFile(paths[i]); err != nil {
+			return nil, err
+		}
+	}
+	if c.configs == nil {
+		c.configs = make(map[string]*config)
+	}
+	c.configs[dir] = cfg
+	return cfg, nil
+}
+
+// decodeFile sets the settings found in the configuration file at path,
+// leaving the others untouched.
+func (cfg *config) decodeFile(path string) error {
+	var settings map[string]any
+	md, err := toml.DecodeFile(path, &settings)
+	if err != nil {
+		var perr toml.ParseError
+		if errors.As(err, &perr) {
+			return fmt.Errorf("%s:%d: %s", path, perr.Position.Line, perr.Message)
+		}
+		return err
+	}
+	// in the order they appear in, so that the first error is reported
+	seen := make(map[string]bool)
+	for _, key := range md.Keys() {
+		// the keys within tables are part of the top-level one
+		name := key[0]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if err := cfg.set(name, settings[name]); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+func (cfg *config) set(key string, value any) error {
+	switch key {
+	case "ignore-interfaces":
+		return setStrings(&cfg.IgnoreInterfaces, key, value)
+	case "ignore-types":
+		return setStrings(&cfg.IgnoreTypes, key, value)
+	case "exclude-packages":
+		return setStrings(&cfg.ExcludePackages, key, value)
+	case "exported-only":
+		return setBool(&cfg.ExportedOnly, key, value)
+	case "import-depth":
+		n, ok := value.(int64)
+		if !ok || n < 0 {
+			return fmt.Errorf("%s must be a non-negative integer", key)
+		}
+		cfg.ImportDepth = int(n)
+	case "module-interfaces":
+		return setBool(&cfg.ModuleInterfaces, key, value)
+	case "std-interfaces":
+		return setBool(&cfg.StdInterfaces, key, value)
+	case "interface-packages":
+		return setStrings(&cfg.InterfacePackages, key, value)
+	case "skip-unexported-by-value":
+		return setBool(&cfg.SkipUnexportedByValue, key, value)
+	case "mention-name":
+		return setBool(&cfg.MentionName, key, value)
+	default:
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	return nil
+}
+
+func setStrings(dst *[]string, key string, value any) error {
+	list, ok := value.([]any)
+	if !ok {
+		return fmt.Errorf("%s must be an array of strings", key)
+	}
+	strs := make([]string, len(list))
+	for i, elem := range list {
+		if strs[i], ok = elem.(string); !ok {
+			return fmt.Errorf("%s must be an array of strings", key)
+		}
+	}
+	*dst = strs
+	return nil
+}
+
+func setBool(dst *bool, key string, value any) error {
+	b, ok := value.(bool)
+	if !ok {
+		return fmt.Errorf("%s must be true or false", key)
+	}
+	*dst = b
+	return nil
+}
+
+// excluded reports whether the package with the given import path
+// should not be checked.
+func (cfg *config) excluded(pkgPath string) bool {
+	for _, pattern := range cfg.ExcludePackages {
+		if matchPackage(pattern, pkgPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPackage reports whether the import path pkgPath matches pattern,
+// which may end in "/..." to match a path and all the ones below it.
+func matchPackage(pattern, pkgPath string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/..."); ok {
+		return pkgPath == prefix || strings.HasPrefix(pkgPath, prefix+"/")
+	}
+	ok, _ := path.Match(pattern, pkgPath)
+	return ok
+}
+
+// providerPatterns returns the package patterns to load so that all the
+// packages searched for interfaces are available, given modPath, the
+// module of the package being checked.
+func (cfg *config) providerPatterns(modPath string) []string {
+	var patterns []string
+	if cfg.StdInterfaces {
+		patterns = append(patterns, "std")
+	}
+	if cfg.ModuleInterfaces && modPath != "" {
+		patterns = append(patterns, modPath+"/...")
+	}
+	return append(patterns, cfg.InterfacePackages...)
+}
+
+// provides reports whether the package with the given import path is
+// searched for interfaces, besides the imports up to ImportDepth.
+// modPath is the module of the package being checked.
+func (cfg *config) provides(pkgPath, modPath string) bool {
+	switch {
+	case cfg.StdInterfaces && isStdPath(pkgPath):
+		return true
+	case cfg.ModuleInterfaces && modPath != "" && matchPackage(modPath+"/...", pkgPath):
+		return true
+	}
+	for _, pattern := range cfg.InterfacePackages {
+		if matchPackage(pattern, pkgPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// isStdPath reports whether pkgPath is in the standard library, which
+// is the case when its first element has no dot, like "go list" does.
+func isStdPath(pkgPath string) bool {
+	first, _, _ := strings.Cut(pkgPath, "/")
+	return !strings.Contains(first, ".")
+}
+
+// suggestable reports whether tn may be suggested for the variables in
+// package pkg.
+func (cfg *config) suggestable(tn *types.TypeName, pkg *types.Package) bool {
+	if !tn.Exported() && (cfg.ExportedOnly || tn.Pkg() != pkg) {
+		return false
+	}
+	for _, name := range cfg.IgnoreInterfaces {
+		if name == fullName(tn) {
+			return false
+		}
+	}
+	return true
+}
+
+// ignoredType reports whether the variables of type t should keep it,
+// either because the config names t or an interface that it implements.
+func (c *Checker) ignoredType(t types.Type) bool {
+	if len(c.cfg.IgnoreTypes) == 0 {
+		return false
+	}
+	name := types.TypeString(t, func(pkg *types.Package) string {
+		return pkg.Path()
+	})
+	for _, ignored := range c.cfg.IgnoreTypes {
+		if ignored == name {
+			return true
+		}
+		if _, ok := t.(*types.TypeParam); ok || types.IsInterface(t) {
+			continue
+		}
+		if iface := c.lookupIface(ignored); iface != nil && types.Implements(t, iface) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupIface returns the interface with the given full name, such as
+// "context.Context", if it is declared in the package being checked or
+// in any of its dependencies.
+func (c *Checker) lookupIface(name string) *types.Interface {
+	i := strings.LastIndex(name, ".")
+	if i < 0 {
+		return nil
+	}
+	pkgPath, tname := name[:i], name[i+1:]
+	seen := make(map[*types.Package]bool)
+	var find func(pkg *types.Package) *types.Package
+	find = func(pkg *types.Package) *types.Package {
+		if seen[pkg] {
+			return nil
+		}
+		seen[pkg] = true
+		if pkg.Path() == pkgPath {
+			return pkg
+		}
+		for _, imp := range pkg.Imports() {
+			if found := find(imp); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+	pkg := find(c.pkg)
+	if pkg == nil {
+		return nil
+	}
+	tn, ok := pkg.Scope().Lookup(tname).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	iface, _ := tn.Type().Underlying().(*types.Interface)
+	return iface
+}
@@ -0,0 +1,218 @@
+// Copyright (c) 2015, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package check
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+const (
+	ignoreDirective     = "interfacer:ignore"
+	ignoreFileDirective = "interfacer:ignore-file"
+)
+
+// directive is a comment suppressing issues, such as:
+//
+//	//interfacer:ignore
+//	//interfacer:ignore f, g // reason
+//	//interfacer:ignore-file // reason
+//
+// The first form goes in the doc comment of a func, or of a struct field
+// or interface method, and suppresses all of its issues. The second one
+// only suppresses the issues of the named parameters. The last one can
+// be anywhere in a file, and suppresses all of the issues in it.
+type directive struct {
+	pos  token.Pos
+	text string
+
+	// file is the name of the file to suppress issues in, for
+	// ignore-file directives.
+	file string
+	// obj is the func or struct field the directive is attached to.
+	obj types.Object
+	// names are the parameters to suppress issues in, or all of them
+	// if empty.
+	names []string
+
+	// used are the names that suppressed an issue, with "" standing
+	// for the entire directive.
+	used map[string]bool
+}
+
+// parseDirective parses the text of a comment, returning the names that
+// follow a directive with the given prefix. ok is false if the comment
+// isn't such a directive.
+func parseDirective(text, prefix string) (names []string, ok bool) {
+	text, ok = strings.CutPrefix(text, "//"+prefix)
+	if !ok {
+		return nil, false
+	}
+	if text, _, _ = strings.Cut(text, "//"); text != "" && text[0] != ' ' && text[0] != '\t' {
+		// a longer directive, such as ignore-file
+		return nil, false
+	}
+	for _, field := range strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	}) {
+		names = append(names, field)
+	}
+	return names, true
+}
+
+// packageDirectives returns the directives in the files of the package.
+// Those attached to interface methods are only considered if interface
+// methods are being checked.
+func (c *Checker) packageDirectives() []*directive {
+	var dirs []*directive
+	attached := func(doc *ast.CommentGroup, obj types.Object) {
+		if doc == nil || obj == nil {
+			return
+		}
+		for _, cm := range doc.List {
+			if names, ok := parseDirective(cm.Text, ignoreDirective); ok {
+				dirs = append(dirs, &directive{
+					pos:   cm.Pos(),
+					text:  cm.Text,
+					obj:   obj,
+					names: names,
+					used:  make(map[string]bool),
+				})
+			}
+		}
+	}
+	for _, f := range c.files {
+		filename := c.fset.Position(f.Pos()).Filename
+		for _, group := range f.Comments {
+			for _, cm := range group.List {
+				if _, ok := parseDirective(cm.Text, ignoreFileDirective); ok {
+					dirs = append(dirs, &directive{
+						pos:  cm.Pos(),
+						text: cm.Text,
+						file: filename,
+						used: make(map[string]bool),
+					})
+				}
+			}
+		}
+		ast.Inspect(f, func(node ast.Node) bool {
+			switch x := node.(type) {
+			case *ast.FuncDecl:
+				attached(x.Doc, c.Defs[x.Name])
+			case *ast.StructType:
+				for _, field := range x.Fields.List {
+					for _, name := range field.Names {
+						attached(field.Doc, c.Defs[name])
+						attached(field.Comment, c.Defs[name])
+					}
+				}
+			case *ast.InterfaceType:
+				if !c.ifaceMethods {
+					break
+				}
+				for _, field := range x.Methods.List {
+					for _, name := range field.Names {
+						attached(field.Doc, c.Defs[name])
+						attached(field.Comment, c.Defs[name])
+					}
+				}
+			}
+			return true
+		})
+	}
+	return dirs
+}
+
+// suppress returns the issues that no directive suppresses. If mark is
+// true, the directives suppressing any issues are marked as used.
+func (c *Checker) suppress(issues []Issue, mark bool) []Issue {
+	if len(c.directives) == 0 {
+		return issues
+	}
+	var kept []Issue
+	for _, issue := range issues {
+		if !c.suppressed(issue, mark) {
+			kept = append(kept, issue)
+		}
+	}
+	return kept
+}
+
+func (c *Checker) suppressed(issue Issue, mark bool) bool {
+	var obj types.Object = issue.Func
+	if issue.Struct != nil {
+		obj = issue.Param
+	}
+	suppressed := false
+	for _, d := range c.directives {
+		name := ""
+		switch {
+		case d.file != "":
+			if d.file != issue.Position.Filename {
+				continue
+			}
+		case d.obj != obj || obj == nil:
+			continue
+		case len(d.names) > 0:
+			name = issue.paramName
+			if !containsName(d.names, name) {
+				continue
+			}
+		}
+		suppressed = true
+		if mark {
+			d.used[name] = true
+		}
+	}
+	return suppressed
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// unusedDirectives returns an issue for each directive that didn't
+// suppress any issues, or that names parameters without any.
+func (c *Checker) unusedDirectives() []Issue {
+	var issues []Issue
+	for _, d := range c.directives {
+		var unused []string
+		for _, name := range d.names {
+			if !d.used[name] {
+				unused = append(unused, name)
+			}
+		}
+		if len(d.names) == 0 && d.used[""] || len(d.names) > 0 && len(unused) == 0 {
+			continue
+		}
+		end := d.pos + token.Pos(len(d.text))
+		issues = append(issues, Issue{
+			Directive:   strings.TrimPrefix(d.text, "//"),
+			Position:    c.fset.Position(d.pos),
+			EndPosition: c.fset.Position(end),
+			pos:         d.pos,
+			end:         end,
+			paramName:   strings.Join(unused, ", "),
+		})
+	}
+	return issues
+}
+
+// directiveMessage returns the message for an issue about an unused
+// directive.
+func (i Issue) directiveMessage() string {
+	name, _, _ := strings.Cut(i.Directive, " ")
+	if i.paramName != "" {
+		return fmt.Sprintf("unused //%s directive for %s", name, i.paramName)
+	}
+	return fmt.Sprintf("unused //%s directive", name)
+}
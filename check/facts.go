@@ -0,0 +1,248 @@
+// Copyright (c) 2015, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package check
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// ifacesFact describes the interfaces declared in a package and in all
+// of its dependencies, so that the analyzer can suggest them in the
+// packages importing it. With drivers such as "go vet", the dependencies
+// only come from export data, which lacks the declarations that the
+// directly imported packages don't use, such as io.Closer for a package
+// only importing os. The facts of indirect dependencies may be missing
+// too, hence why each fact covers all of them.
+type ifacesFact struct {
+	Pkgs []factPkg
+}
+
+// factPkg lists the exported interfaces of a package, whose method sets
+// are identified by methodsKey, along with its imports.
+type factPkg struct {
+	Path    string
+	Name    string
+	Imports []string
+	Ifaces  []factIface
+}
+
+type factIface struct {
+	Name string
+	Key  string
+}
+
+func (*ifacesFact) AFact() {}
+
+func (f *ifacesFact) String() string {
+	return fmt.Sprintf("interfaces(%d packages)", len(f.Pkgs))
+}
+
+// newIfacesFact returns the fact for pkg, which must have been loaded
+// from source, given the packages in the facts of its dependencies.
+func newIfacesFact(pkg *types.Package, deps map[string]*factPkg) *ifacesFact {
+	own := factPkg{Path: pkg.Path(), Name: pkg.Name()}
+	for _, imp := range pkg.Imports() {
+		own.Imports = append(own.Imports, imp.Path())
+	}
+	ifs, _, _, _ := fromScope(pkg.Scope())
+	ifs.Iterate(func(_ types.Type, value any) {
+		tn := value.(*types.TypeName)
+		if !tn.Exported() {
+			return
+		}
+		if key := methodsKey(typeFuncMap(tn.Type())); key != "" {
+			own.Ifaces = append(own.Ifaces, factIface{Name: tn.Name(), Key: key})
+		}
+	})
+	sort.Slice(own.Ifaces, func(i, j int) bool {
+		return own.Ifaces[i].Name < own.Ifaces[j].Name
+	})
+	fact := &ifacesFact{Pkgs: []factPkg{own}}
+	for _, p := range sortedKeys(deps) {
+		if p != own.Path {
+			fact.Pkgs = append(fact.Pkgs, *deps[p])
+		}
+	}
+	return fact
+}
+
+// factPkgs merges the packages in the facts available to pass.
+func factPkgs(pass *analysis.Pass) map[string]*factPkg {
+	pkgs := make(map[string]*factPkg)
+	for _, pf := range pass.AllPackageFacts() {
+		fact, ok := pf.Fact.(*ifacesFact)
+		if !ok {
+			continue
+		}
+		for i := range fact.Pkgs {
+			fp := &fact.Pkgs[i]
+			if pkgs[fp.Path] == nil {
+				pkgs[fp.Path] = fp
+			}
+		}
+	}
+	return pkgs
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// methodsKey returns a string identifying the method set, with the types
+// in the signatures qualified by their full package paths. It returns an
+// empty string if any of the methods has no signature.
+func methodsKey(funcs funcMap) string {
+	names := make([]string, 0, len(funcs))
+	for name, sign := range funcs {
+		if sign == nil {
+			return ""
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		sign := funcs[name]
+		b.WriteString(name)
+		writeTupleKey(&b, sign.Params(), sign.Variadic())
+		writeTupleKey(&b, sign.Results(), false)
+		b.WriteByte(';')
+	}
+	return b.String()
+}
+
+func writeTupleKey(b *strings.Builder, tuple *types.Tuple, variadic bool) {
+	b.WriteByte('(')
+	for i := 0; i < tuple.Len(); i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		t := types.Unalias(tuple.At(i).Type())
+		if variadic && i == tuple.Len()-1 {
+			b.WriteString("...")
+			t = types.Unalias(t.(*types.Slice).Elem())
+		}
+		if iface, ok := t.(*types.Interface); ok && iface.Empty() {
+			// interface{} and any print differently
+			b.WriteString("any")
+			continue
+		}
+		b.WriteString(types.TypeString(t, (*types.Package).Path))
+	}
+	b.WriteByte(')')
+}
+
+// factIfaces gathers the interfaces that pass.Pkg could use as recorded
+// in facts, the packages in the facts of its dependencies, mapped by
+// methodsKey. Like getTypes, it follows the imports up to the depth set
+// by cfg, plus the packages that cfg adds, and the closer interfaces take
+// precedence.
+func factIfaces(pass *analysis.Pass, cfg *config, facts map[string]*factPkg) map[string]*types.TypeName {
+	// the packages already loaded, so that the same objects are used
+	// when the export data has them
+	loaded := make(map[string]*types.Package)
+	var addLoaded func(pkg *types.Package)
+	addLoaded = func(pkg *types.Package) {
+		if loaded[pkg.Path()] != nil {
+			return
+		}
+		loaded[pkg.Path()] = pkg
+		for _, imp := range pkg.Imports() {
+			addLoaded(imp)
+		}
+	}
+	addLoaded(pass.Pkg)
+
+	levels := map[string]int{pass.Pkg.Path(): 0}
+	queue := []string{pass.Pkg.Path()}
+	for ; len(queue) > 0; queue = queue[1:] {
+		p := queue[0]
+		var imports []string
+		if p == pass.Pkg.Path() {
+			for _, imp := range pass.Pkg.Imports() {
+				imports = append(imports, imp.Path())
+			}
+		} else if fact := facts[p]; fact != nil {
+			imports = fact.Imports
+		}
+		for _, imp := range imports {
+			if _, e := levels[imp]; !e {
+				levels[imp] = levels[p] + 1
+				queue = append(queue, imp)
+			}
+		}
+	}
+	modPath := ""
+	if pass.Module != nil {
+		modPath = pass.Module.Path
+	}
+	var paths []string
+	for p := range facts {
+		level, e := levels[p]
+		if (e && level <= cfg.ImportDepth) || cfg.provides(p, modPath) {
+			paths = append(paths, p)
+		}
+	}
+	// farther packages first, so that the closer ones replace them
+	sort.Slice(paths, func(i, j int) bool {
+		li, ei := levels[paths[i]]
+		lj, ej := levels[paths[j]]
+		if ei != ej {
+			return !ei
+		}
+		if li != lj {
+			return li > lj
+		}
+		return paths[i] < paths[j]
+	})
+	ifaces := make(map[string]*types.TypeName)
+	for _, p := range paths {
+		fact := facts[p]
+		if p == pass.Pkg.Path() || !importablePath(p, fact.Name, pass.Pkg.Path()) {
+			continue
+		}
+		pkg := loaded[p]
+		if pkg == nil {
+			pkg = types.NewPackage(p, fact.Name)
+		}
+		for _, fi := range fact.Ifaces {
+			tn := types.NewTypeName(token.NoPos, pkg, fi.Name, nil)
+			if cfg.suggestable(tn, pass.Pkg) {
+				ifaces[fi.Key] = tn
+			}
+		}
+	}
+	return ifaces
+}
+
+// factMatching returns the interface recorded in the facts whose methods
+// are exactly the ones in called. As only its name is known, the type is
+// rebuilt from the methods in called.
+func (c *Checker) factMatching(called funcMap) *suggestion {
+	if len(c.factIfaces) == 0 || len(called) == 0 {
+		return nil
+	}
+	tn := c.factIfaces[methodsKey(called)]
+	if tn == nil {
+		return nil
+	}
+	if obj, ok := tn.Pkg().Scope().Lookup(tn.Name()).(*types.TypeName); ok {
+		// in the export data after all
+		return &suggestion{tn: obj, funcs: called}
+	}
+	iface := called.iface()
+	named := types.NewNamed(types.NewTypeName(token.NoPos, tn.Pkg(), tn.Name(), nil), iface, nil)
+	return &suggestion{tn: named.Obj(), funcs: called}
+}
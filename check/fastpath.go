@@ -0,0 +1,98 @@
+// Copyright (c) 2015, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package check
+
+import (
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// maxFastPathDepth is how many static calls deep a parameter is followed
+// when looking for type assertions on it, such as io.Copy passing its
+// source on to the func that checks for io.WriterTo.
+const maxFastPathDepth = 4
+
+// addFastPaths sets the optional interfaces hidden by each issue. Those
+// are the interfaces that the value of the parameter is type-asserted
+// to by the funcs it's passed to, which its old type implements but its
+// new type doesn't. The checks would still work for the values passed
+// today, but the new signature no longer guarantees them.
+func (c *Checker) addFastPaths(issues []Issue) {
+	for i, issue := range issues {
+		if issue.Func == nil {
+			continue
+		}
+		fn := c.ssaByPos[issue.Func.Pos()]
+		if fn == nil {
+			continue
+		}
+		for _, p := range fn.Params {
+			if p.Object() != issue.Param {
+				continue
+			}
+			var asserted typeutil.Map
+			c.assertedIfaces(p, &asserted, make(map[ssa.Value]bool), 0)
+			issues[i].FastPaths = hiddenIfaces(issue, &asserted)
+		}
+	}
+}
+
+// assertedIfaces adds the interface types that v is type-asserted to
+// into asserted. The value is followed
+// through interface conversions and as an argument to static calls.
+// Packages that haven't been built yet are built as they are reached,
+// if the checker loaded them itself.
+func (c *Checker) assertedIfaces(v ssa.Value, asserted *typeutil.Map, seen map[ssa.Value]bool, depth int) {
+	if seen[v] || v.Referrers() == nil {
+		return
+	}
+	seen[v] = true
+	for _, instr := range *v.Referrers() {
+		switch x := instr.(type) {
+		case *ssa.TypeAssert:
+			if x.X == v && types.IsInterface(x.AssertedType) {
+				asserted.Set(x.AssertedType, true)
+			}
+		case *ssa.MakeInterface:
+			c.assertedIfaces(x, asserted, seen, depth)
+		case *ssa.ChangeInterface:
+			c.assertedIfaces(x, asserted, seen, depth)
+		case ssa.CallInstruction:
+			callee := x.Common().StaticCallee()
+			if callee == nil || depth >= maxFastPathDepth {
+				continue
+			}
+			if len(callee.Blocks) == 0 && callee.Pkg != nil && c.lazyDeps {
+				callee.Pkg.Build()
+			}
+			if len(callee.Blocks) == 0 {
+				continue
+			}
+			for i, arg := range x.Common().Args {
+				if arg == v && i < len(callee.Params) {
+					c.assertedIfaces(callee.Params[i], asserted, seen, depth+1)
+				}
+			}
+		}
+	}
+}
+
+// hiddenIfaces returns the types in asserted that the old type in issue
+// implements but the new one doesn't, sorted by their names.
+func hiddenIfaces(issue Issue, asserted *typeutil.Map) []types.Type {
+	var hidden []types.Type
+	for _, t := range asserted.Keys() {
+		iface := t.Underlying().(*types.Interface)
+		if types.Implements(issue.OldType, iface) && !types.Implements(issue.NewType, iface) {
+			hidden = append(hidden, t)
+		}
+	}
+	sort.Slice(hidden, func(i, j int) bool {
+		return hidden[i].String() < hidden[j].String()
+	})
+	return hidden
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2015, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package check
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+)
+
+// structFields returns the struct fields declared in pkg whose uses can
+// all be seen within it, mapped to the struct types declaring them.
+// Those are the unexported and non-embedded fields of the non-generic
+// struct types declared at the top level.
+func structFields(pkg *types.Package) map[*types.Var]*types.TypeName {
+	fields := make(map[*types.Var]*types.TypeName)
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		tn, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || tn.IsAlias() {
+			continue
+		}
+		named, ok := tn.Type().(*types.Named)
+		if !ok || named.TypeParams().Len() > 0 {
+			continue
+		}
+		st, ok := named.Underlying().(*types.Struct)
+		if !ok {
+			continue
+		}
+		for i := 0; i < st.NumFields(); i++ {
+			field := st.Field(i)
+			if field.Exported() || field.Embedded() {
+				continue
+			}
+			fields[field] = tn
+		}
+	}
+	return fields
+}
+
+// testsMissing reports whether there are test files in the same package
+// as pkg that weren't loaded, such as when not checking the tests. The
+// tracked fields can't be narrowed then, as those may use them too.
+func (c *Checker) testsMissing(pkg *types.Package) bool {
+	if len(c.files) == 0 {
+		return false
+	}
+	loaded := make(map[string]bool, len(c.files))
+	for _, f := range c.files {
+		loaded[c.fset.File(f.Pos()).Name()] = true
+	}
+	dir := filepath.Dir(c.fset.File(c.files[0].Pos()).Name())
+	paths, _ := filepath.Glob(filepath.Join(dir, "*_test.go"))
+	for _, path := range paths {
+		if loaded[path] {
+			continue
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.PackageClauseOnly)
+		if err == nil && f.Name.Name == pkg.Name() {
+			return true
+		}
+	}
+	return false
+}
+
+// trackedField returns the tracked struct field selected by e, if any.
+func (c *Checker) trackedField(e ast.Expr) *types.Var {
+	sel, ok := ast.Unparen(e).(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+	s := c.Selections[sel]
+	if s == nil || s.Kind() != types.FieldVal {
+		return nil
+	}
+	field := s.Obj().(*types.Var)
+	if c.fields[field] == nil {
+		return nil
+	}
+	return field
+}
+
+// compositeField returns the tracked struct field set by an element of
+// a composite literal of type t, either by its key or by its index i
+// if there is no key.
+func (c *Checker) compositeField(t types.Type, key ast.Expr, i int) *types.Var {
+	st, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+	var field *types.Var
+	if key == nil {
+		field = st.Field(i)
+	} else if id, ok := key.(*ast.Ident); ok {
+		field, _ = c.ObjectOf(id).(*types.Var)
+	}
+	if field == nil || c.fields[field] == nil {
+		return nil
+	}
+	return field
+}
+
+// fieldIssues returns the issues in the tracked struct fields, with
+// their usage aggregated across the whole package.
+func (c *Checker) fieldIssues() []Issue {
+	fields := make([]*types.Var, 0, len(c.fields))
+	for field := range c.fields {
+		fields = append(fields, field)
+	}
+	// in a stable order, for the names of synthesized interfaces
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Pos() < fields[j].Pos()
+	})
+	var issues []Issue
+	for _, field := range fields {
+		tn := c.fields[field]
+		usage := c.vars[field]
+		if usage == nil || willAddAllocation(field.Type()) {
+			continue
+		}
+		sugg := c.newType(field.Type(), usage, tn.Exported())
+		if sugg == nil {
+			continue
+		}
+		issue := c.newIssue(field, sugg)
+		issue.Struct = tn
+		issue.needs = neededBy(usage)
+		issues = append(issues, issue)
+	}
+	return issues
+}
@@ -0,0 +1,375 @@
+// Copyright (c) 2015, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package check
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// Fix applies the suggestions in issues to the source files declaring
+// the parameters. It returns the new contents of each modified file,
+// keyed by filename. Imports are added or reused as needed, and the
+// result is formatted with gofmt.
+//
+// Synthesized interfaces are declared in a file named interfaces.go
+// next to each function, created if needed.
+func Fix(issues []Issue) (map[string][]byte, error) {
+	return fix(issues, true)
+}
+
+// Declare is like Fix, but it only declares the synthesized interfaces
+// without changing any parameters.
+func Declare(issues []Issue) (map[string][]byte, error) {
+	return fix(issues, false)
+}
+
+// edit is a change of a parameter's type, as suggested by an issue.
+type edit struct {
+	pos   token.Position
+	issue Issue
+}
+
+// fileEdits groups the edits needed by issues by filename. If params is
+// false, only the declarations of synthesized interfaces are included.
+// The package name for each file is also returned, to create new files.
+func fileEdits(issues []Issue, params bool) (edits map[string][]edit, decls map[string][]Issue, pkgNames map[string]string) {
+	edits = make(map[string][]edit)
+	decls = make(map[string][]Issue)
+	pkgNames = make(map[string]string)
+	declared := make(map[types.Type]bool)
+	for _, issue := range issues {
+		if issue.Directive != "" {
+			// nothing to change
+			continue
+		}
+		if params {
+			for _, pos := range issue.positions() {
+				edits[pos.Filename] = append(edits[pos.Filename], edit{pos, issue})
+			}
+		}
+		if issue.Synthesized && !declared[issue.NewType] {
+			declared[issue.NewType] = true
+			name := declFile(issue)
+			decls[name] = append(decls[name], issue)
+			pkgNames[name] = issue.pkg().Name()
+		}
+	}
+	return edits, decls, pkgNames
+}
+
+func fix(issues []Issue, params bool) (map[string][]byte, error) {
+	edits, decls, pkgNames := fileEdits(issues, params)
+	fixed := make(map[string][]byte, len(edits))
+	fixName := func(name string) error {
+		if _, e := fixed[name]; e {
+			return nil
+		}
+		src, err := os.ReadFile(name)
+		if os.IsNotExist(err) && pkgNames[name] != "" {
+			src, err = []byte("package "+pkgNames[name]+"\n"), nil
+		}
+		if err != nil {
+			return err
+		}
+		out, err := fixFile(name, src, edits[name], decls[name])
+		if err != nil {
+			return err
+		}
+		fixed[name] = out
+		return nil
+	}
+	for name := range edits {
+		if err := fixName(name); err != nil {
+			return nil, err
+		}
+	}
+	for name := range decls {
+		if err := fixName(name); err != nil {
+			return nil, err
+		}
+	}
+	return fixed, nil
+}
+
+type fileFixer struct {
+	fset *token.FileSet
+	file *ast.File
+	tok  *token.File
+	// pkg is the package of the file, if known
+	pkg *types.Package
+
+	byOffset map[int]Issue
+
+	// importsChanged is true if any imports were added or removed
+	importsChanged bool
+}
+
+// fixFile applies edits to a file, and appends the declarations of the
+// interfaces synthesized for decls.
+func fixFile(name string, src []byte, edits []edit, decls []Issue) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, name, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	ff := &fileFixer{
+		fset:     fset,
+		file:     f,
+		tok:      fset.File(f.Pos()),
+		byOffset: make(map[int]Issue, len(edits)),
+	}
+	for _, e := range edits {
+		ff.byOffset[e.pos.Offset] = e.issue
+		ff.pkg = e.issue.pkg()
+	}
+	for _, issue := range decls {
+		ff.pkg = issue.pkg()
+	}
+	var used []*ast.ImportSpec
+	for _, imp := range f.Imports {
+		if p, _ := strconv.Unquote(imp.Path.Value); astutil.UsesImport(f, p) {
+			used = append(used, imp)
+		}
+	}
+	// covers func declarations, interface methods, type parameters
+	// and struct fields
+	ast.Inspect(f, func(node ast.Node) bool {
+		var lists []*ast.FieldList
+		switch x := node.(type) {
+		case *ast.FuncType:
+			lists = []*ast.FieldList{x.TypeParams, x.Params}
+		case *ast.StructType:
+			lists = []*ast.FieldList{x.Fields}
+		}
+		for _, fields := range lists {
+			if fields == nil {
+				continue
+			}
+			var list []*ast.Field
+			for _, field := range fields.List {
+				list = append(list, ff.fixField(field)...)
+			}
+			fields.List = list
+		}
+		return true
+	})
+	// the old parameter types may have been the only uses of imports
+	for _, imp := range used {
+		p, _ := strconv.Unquote(imp.Path.Value)
+		if astutil.UsesImport(f, p) {
+			continue
+		}
+		name := ""
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		astutil.DeleteNamedImport(fset, f, name, p)
+		ff.importsChanged = true
+	}
+	var texts []string
+	for _, issue := range decls {
+		texts = append(texts, ff.declText(issue))
+	}
+	sort.Strings(texts)
+	if ff.importsChanged {
+		ff.collapseImports()
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, f); err != nil {
+		return nil, err
+	}
+	if len(texts) == 0 {
+		return buf.Bytes(), nil
+	}
+	for _, text := range texts {
+		buf.WriteString("\n" + text)
+	}
+	return format.Source(buf.Bytes())
+}
+
+// fixField returns the fields that should replace field. A parameter
+// group such as "a, b T" is kept together if all of its names get the
+// same new type, and split otherwise.
+func (ff *fileFixer) fixField(field *ast.Field) []*ast.Field {
+	if len(field.Names) == 0 {
+		// unnamed parameters are found by their type
+		if issue, ok := ff.byOffset[ff.tok.Offset(field.Type.Pos())]; ok {
+			field.Type = ff.typeExpr(issue, field.Type.Pos())
+		}
+		return []*ast.Field{field}
+	}
+	exprs := make([]ast.Expr, len(field.Names))
+	keys := make([]string, len(field.Names))
+	for i, name := range field.Names {
+		issue, ok := ff.byOffset[ff.tok.Offset(name.Pos())]
+		if !ok {
+			exprs[i] = field.Type
+			continue
+		}
+		exprs[i] = ff.typeExpr(issue, field.Type.Pos())
+		keys[i] = issue.NewType.String()
+	}
+	var fields []*ast.Field
+	for i, name := range field.Names {
+		if i > 0 && keys[i] == keys[i-1] {
+			last := fields[len(fields)-1]
+			last.Names = append(last.Names, name)
+			continue
+		}
+		fields = append(fields, &ast.Field{
+			Names: []*ast.Ident{name},
+			Type:  exprs[i],
+		})
+	}
+	if len(fields) == 1 {
+		// keep the doc and line comments
+		field.Type = fields[0].Type
+		return []*ast.Field{field}
+	}
+	return fields
+}
+
+// typeExpr returns the expression to use for the suggested type in the
+// issue, adding imports to the file if needed. The expression is placed
+// at pos, so that the printer keeps the surrounding layout.
+func (ff *fileFixer) typeExpr(issue Issue, pos token.Pos) ast.Expr {
+	return ff.typeToExpr(issue.NewType, issue.pkg(), pos)
+}
+
+// typeToExpr returns the expression for t as written in package pkg,
+// including the type arguments of instantiated generic types.
+func (ff *fileFixer) typeToExpr(t types.Type, pkg *types.Package, pos token.Pos) ast.Expr {
+	switch x := t.(type) {
+	case *types.Basic:
+		return &ast.Ident{NamePos: pos, Name: x.Name()}
+	case *types.Pointer:
+		return &ast.StarExpr{Star: pos, X: ff.typeToExpr(x.Elem(), pkg, pos)}
+	case *types.Slice:
+		return &ast.ArrayType{Lbrack: pos, Elt: ff.typeToExpr(x.Elem(), pkg, pos)}
+	case *types.Array:
+		return &ast.ArrayType{
+			Lbrack: pos,
+			Len:    &ast.BasicLit{ValuePos: pos, Kind: token.INT, Value: strconv.FormatInt(x.Len(), 10)},
+			Elt:    ff.typeToExpr(x.Elem(), pkg, pos),
+		}
+	case *types.Map:
+		return &ast.MapType{
+			Map:   pos,
+			Key:   ff.typeToExpr(x.Key(), pkg, pos),
+			Value: ff.typeToExpr(x.Elem(), pkg, pos),
+		}
+	case *types.Interface:
+		if x.Empty() {
+			return &ast.InterfaceType{Interface: pos, Methods: &ast.FieldList{Opening: pos, Closing: pos}}
+		}
+	case *types.Named, *types.Alias:
+		obj := typeObj(x)
+		var expr ast.Expr = &ast.Ident{NamePos: pos, Name: obj.Name()}
+		if obj.Pkg() != nil && obj.Pkg() != pkg {
+			qual := ff.importName(importPath(obj.Pkg().Path()), obj.Pkg().Name())
+			if qual != "" {
+				expr = &ast.SelectorExpr{X: &ast.Ident{NamePos: pos, Name: qual}, Sel: expr.(*ast.Ident)}
+			}
+		}
+		named, ok := x.(*types.Named)
+		if !ok || named.TypeArgs().Len() == 0 {
+			return expr
+		}
+		args := make([]ast.Expr, named.TypeArgs().Len())
+		for i := range args {
+			args[i] = ff.typeToExpr(named.TypeArgs().At(i), pkg, pos)
+		}
+		if len(args) == 1 {
+			return &ast.IndexExpr{X: expr, Lbrack: pos, Index: args[0], Rbrack: pos}
+		}
+		return &ast.IndexListExpr{X: expr, Lbrack: pos, Indices: args, Rbrack: pos}
+	}
+	// not expected in type arguments; print it as go/types would
+	return &ast.Ident{NamePos: pos, Name: types.TypeString(t, types.RelativeTo(pkg))}
+}
+
+// importName returns the name by which the file refers to the package
+// with the given import path, adding an import for it if there isn't
+// one already. An empty name means the package is dot-imported.
+func (ff *fileFixer) importName(ipath, pkgName string) string {
+	if name, ok := importedName(ff.file, ipath, pkgName); ok {
+		return name
+	}
+	name := pkgName
+	for i := 2; ff.nameTaken(name); i++ {
+		name = fmt.Sprintf("%s%d", pkgName, i)
+	}
+	if name == pkgName {
+		astutil.AddImport(ff.fset, ff.file, ipath)
+	} else {
+		astutil.AddNamedImport(ff.fset, ff.file, name, ipath)
+	}
+	ff.importsChanged = true
+	return name
+}
+
+// collapseImports drops the parentheses from import declarations left
+// with a single import, like goimports does.
+func (ff *fileFixer) collapseImports() {
+	for _, decl := range ff.file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			break
+		}
+		if len(gen.Specs) == 1 && gen.Specs[0].(*ast.ImportSpec).Doc == nil {
+			gen.Lparen = token.NoPos
+		}
+	}
+}
+
+func (ff *fileFixer) nameTaken(name string) bool {
+	for _, imp := range ff.file.Imports {
+		local := ""
+		if imp.Name != nil {
+			local = imp.Name.Name
+		} else if p, err := strconv.Unquote(imp.Path.Value); err == nil {
+			local = path.Base(p)
+		}
+		if local == name {
+			return true
+		}
+	}
+	if ff.file.Scope.Lookup(name) != nil {
+		return true
+	}
+	// declared in another file of the package
+	return ff.pkg != nil && ff.pkg.Scope().Lookup(name) != nil
+}
+
+// importPath returns the path with which a package is imported, which
+// differs from its full path for vendored packages.
+func importPath(p string) string {
+	if i := strings.LastIndex(p, "/vendor/"); i >= 0 {
+		return p[i+len("/vendor/"):]
+	}
+	return strings.TrimPrefix(p, "vendor/")
+}
+
+func typeObj(t types.Type) *types.TypeName {
+	switch x := t.(type) {
+	case *types.Named:
+		return x.Obj()
+	case *types.Alias:
+		return x.Obj()
+	}
+	return nil
+}
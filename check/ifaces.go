@@ -0,0 +1,195 @@
+// Copyright (c) 2015, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package check
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+)
+
+// implDecl is a method implementing an interface, whose parameters are
+// only checked as part of the interface methods it implements.
+type implDecl struct {
+	fd *funcDecl
+
+	// the state of the package the method was declared in
+	vars         map[*types.Var]*varUsage
+	discardFuncs map[*types.Signature]struct{}
+}
+
+// ifaceMethodIssues returns the issues found in the parameters of the
+// interface methods declared in the checked packages. The usage of a
+// parameter is merged across all the implementations of its method,
+// which must all be declared in the checked packages.
+func (c *Checker) ifaceMethodIssues() []Issue {
+	allIfaces := c.programIfaces()
+	var issues []Issue
+	for _, pkg := range c.pkgs {
+		if pkg.Types == nil || pkg.TypesInfo == nil {
+			continue
+		}
+		if c.cfg = c.pkgConfigs[pkg.Types]; c.cfg == nil || c.cfg.excluded(pkg.PkgPath) {
+			continue
+		}
+		c.getTypes(pkg.Types, c.cfg, c.providersFor(pkg.Types, c.cfg))
+		c.pkg = pkg.Types
+		c.Info = pkg.TypesInfo
+		c.files = pkg.Syntax
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			tn, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || tn.IsAlias() {
+				continue
+			}
+			named, ok := tn.Type().(*types.Named)
+			if !ok || named.TypeParams().Len() > 0 {
+				continue
+			}
+			iface, ok := named.Underlying().(*types.Interface)
+			if !ok {
+				continue
+			}
+			for i := 0; i < iface.NumExplicitMethods(); i++ {
+				m := iface.ExplicitMethod(i)
+				impls := c.implementations(iface, m, allIfaces)
+				if len(impls) == 0 {
+					continue
+				}
+				issues = append(issues, c.methodIssues(m, impls)...)
+			}
+		}
+	}
+	return issues
+}
+
+// programIfaces returns all the named interfaces declared at the top
+// level of the packages in the program.
+func (c *Checker) programIfaces() []*types.Interface {
+	var list []*types.Interface
+	for _, pkg := range c.prog.AllPackages() {
+		_, _, _, named := fromScope(pkg.Pkg.Scope())
+		for _, tn := range named {
+			list = append(list, tn.Type().Underlying().(*types.Interface))
+		}
+	}
+	return list
+}
+
+// implementations returns the methods implementing m, a method of
+// iface, in the concrete types declared in the checked packages. nil is
+// returned if any of them could not be analyzed, or if any of them
+// also implements a method with the same name in another interface.
+func (c *Checker) implementations(iface *types.Interface, m *types.Func, allIfaces []*types.Interface) []*implDecl {
+	var impls []*implDecl
+	seen := make(map[*types.Func]bool)
+	for _, pkg := range c.pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			tn, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || tn.IsAlias() || types.IsInterface(tn.Type()) {
+				continue
+			}
+			if named, ok := tn.Type().(*types.Named); !ok || named.TypeParams().Len() > 0 {
+				continue
+			}
+			t := types.Type(types.NewPointer(tn.Type()))
+			if !types.Implements(t, iface) {
+				continue
+			}
+			obj, _, _ := types.LookupFieldOrMethod(t, false, m.Pkg(), m.Name())
+			fn, _ := obj.(*types.Func)
+			if fn == nil {
+				return nil
+			}
+			if seen[fn] {
+				continue
+			}
+			seen[fn] = true
+			impl := c.impls[fn]
+			if impl == nil {
+				// no body, or declared elsewhere
+				return nil
+			}
+			if _, e := impl.discardFuncs[impl.fd.ssaFn.Signature]; e {
+				return nil
+			}
+			for _, other := range allIfaces {
+				if other == iface || !types.Implements(t, other) {
+					continue
+				}
+				if obj, _, _ := types.LookupFieldOrMethod(other, false, m.Pkg(), m.Name()); obj != nil {
+					// changing its signature would break other
+					return nil
+				}
+			}
+			impls = append(impls, impl)
+		}
+	}
+	sort.Slice(impls, func(i, j int) bool {
+		return impls[i].fd.astDecl.Pos() < impls[j].fd.astDecl.Pos()
+	})
+	return impls
+}
+
+func (c *Checker) methodIssues(m *types.Func, impls []*implDecl) []Issue {
+	var issues []Issue
+	params := m.Type().(*types.Signature).Params()
+	for i := 0; i < params.Len(); i++ {
+		param := params.At(i)
+		merged := &varUsage{assigned: make(map[*varUsage]struct{})}
+		for _, impl := range impls {
+			implParam := impl.fd.ssaFn.Signature.Params().At(i)
+			if usage := impl.vars[implParam]; usage != nil {
+				merged.assigned[usage] = struct{}{}
+			}
+		}
+		if len(merged.assigned) == 0 {
+			// unused everywhere
+			continue
+		}
+		sugg := c.paramNewType(m.Name(), param, merged)
+		if sugg == nil {
+			continue
+		}
+		issues = append(issues, c.newMethodIssue(m, i, impls, sugg))
+	}
+	return issues
+}
+
+func (c *Checker) newMethodIssue(m *types.Func, index int, impls []*implDecl, sugg *suggestion) Issue {
+	param := m.Type().(*types.Signature).Params().At(index)
+	issue := c.newIssue(param, sugg)
+	issue.Func = m
+	issue.ParamIndex = index
+	if name := param.Name(); name == "" || name == "_" {
+		issue.paramName = fmt.Sprintf("parameter %d", index+1)
+		issue.end = issue.pos
+		issue.EndPosition = issue.Position
+	}
+	for _, impl := range impls {
+		fn, _ := impl.fd.ssaFn.Object().(*types.Func)
+		issue.Implementations = append(issue.Implementations, fn)
+		implParam := impl.fd.ssaFn.Signature.Params().At(index)
+		issue.implPositions = append(issue.implPositions, c.fset.Position(implParam.Pos()))
+	}
+	return issue
+}
+
+// methodName returns a short name for a method, such as "(*T).Name",
+// qualifying its receiver type relative to pkg.
+func methodName(fn *types.Func, pkg *types.Package) string {
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return fn.Name()
+	}
+	name := types.TypeString(recv.Type(), types.RelativeTo(pkg))
+	if _, ok := recv.Type().(*types.Pointer); ok {
+		name = "(" + name + ")"
+	}
+	return name + "." + fn.Name()
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2015, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package check
+
+import (
+	"go/types"
+
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// findImplemented records the methods that are used to implement an
+// interface somewhere in fns, as shown by a concrete type being
+// converted to that interface. Conversions between interfaces and type
+// assertions to interfaces are followed, as the concrete types may end
+// up in those interfaces too.
+func (c *Checker) findImplemented(fns map[*ssa.Function]bool) {
+	c.implemented = make(map[*types.Func]bool)
+	var conversions []*ssa.MakeInterface
+	// edges between interface types, keyed by the source type
+	var edges typeutil.Map
+	addEdge := func(from, to types.Type) {
+		if !types.IsInterface(from) || !types.IsInterface(to) {
+			return
+		}
+		list, _ := edges.At(from).([]types.Type)
+		edges.Set(from, append(list, to))
+	}
+	for fn := range fns {
+		for _, block := range fn.Blocks {
+			for _, instr := range block.Instrs {
+				switch x := instr.(type) {
+				case *ssa.MakeInterface:
+					conversions = append(conversions, x)
+				case *ssa.ChangeInterface:
+					addEdge(x.X.Type(), x.Type())
+				case *ssa.TypeAssert:
+					addEdge(x.X.Type(), x.AssertedType)
+				}
+			}
+		}
+	}
+	for _, conv := range conversions {
+		var seen typeutil.Map
+		queue := []types.Type{conv.Type()}
+		for len(queue) > 0 {
+			iface := queue[0]
+			queue = queue[1:]
+			if seen.At(iface) != nil {
+				continue
+			}
+			seen.Set(iface, true)
+			c.markImplemented(conv.X.Type(), iface)
+			list, _ := edges.At(iface).([]types.Type)
+			queue = append(queue, list...)
+		}
+	}
+}
+
+// markImplemented records the methods of t that implement the methods
+// of iface.
+func (c *Checker) markImplemented(t, iface types.Type) {
+	it, ok := iface.Underlying().(*types.Interface)
+	if !ok {
+		return
+	}
+	mset := types.NewMethodSet(t)
+	for i := 0; i < it.NumMethods(); i++ {
+		m := it.Method(i)
+		sel := mset.Lookup(m.Pkg(), m.Name())
+		if sel == nil {
+			continue
+		}
+		if fn, ok := sel.Obj().(*types.Func); ok {
+			c.implemented[fn.Origin()] = true
+		}
+	}
+}
+
+// mayImplement reports whether the method fn may be implementing an
+// interface. That is the case if it was seen doing so, or if its
+// receiver type implements a named interface in scope with a method of
+// the same name.
+func (c *Checker) mayImplement(fn *types.Func) bool {
+	sign := fn.Type().(*types.Signature)
+	recv := sign.Recv()
+	if recv == nil {
+		return false
+	}
+	if c.implemented[fn.Origin()] {
+		return true
+	}
+	t := recv.Type()
+	if _, ok := t.(*types.Pointer); !ok && !types.IsInterface(t) {
+		// the pointer's method set includes the value methods
+		t = types.NewPointer(t)
+	}
+	for _, tn := range c.namedIfaces {
+		iface := tn.Type().Underlying().(*types.Interface)
+		obj, _, _ := types.LookupFieldOrMethod(iface, false, fn.Pkg(), fn.Name())
+		if obj == nil {
+			continue
+		}
+		if types.Implements(t, iface) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2015, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package check
+
+import (
+	"go/types"
+)
+
+// genericMatching returns an instantiation of a known generic interface
+// whose methods are exactly the ones in called, as declared by t. The
+// type arguments are inferred by matching the signatures of the generic
+// methods against the ones of t. Like with the exact matches, the
+// interfaces declared last, such as the ones in the package itself, take
+// precedence.
+func (c *Checker) genericMatching(t types.Type, called funcMap) *suggestion {
+	if len(called) == 0 {
+		return nil
+	}
+	var best *suggestion
+	for _, tn := range c.generics {
+		inst := c.instantiateFor(tn, t, called)
+		if inst == nil || !sameFuncs(typeFuncMap(inst), called) {
+			continue
+		}
+		iface, _ := inst.Underlying().(*types.Interface)
+		if iface == nil || !types.Implements(t, iface) {
+			continue
+		}
+		best = &suggestion{tn: tn, typ: inst, funcs: called}
+	}
+	return best
+}
+
+// instantiateFor instantiates the generic interface tn with the type
+// arguments that make its methods match the ones of t, if there are any.
+func (c *Checker) instantiateFor(tn *types.TypeName, t types.Type, called funcMap) types.Type {
+	named := tn.Type().(*types.Named)
+	iface := named.Underlying().(*types.Interface)
+	if iface.NumMethods() != len(called) {
+		return nil
+	}
+	u := unifier{tparams: named.TypeParams(), bound: make(map[*types.TypeParam]types.Type)}
+	for i := 0; i < iface.NumMethods(); i++ {
+		m := iface.Method(i)
+		if _, e := called[m.Name()]; !e {
+			return nil
+		}
+		obj, _, _ := types.LookupFieldOrMethod(t, true, c.pkg, m.Name())
+		fn, ok := obj.(*types.Func)
+		if !ok || !u.unify(m.Type(), fn.Type()) {
+			return nil
+		}
+	}
+	args := make([]types.Type, u.tparams.Len())
+	for i := range args {
+		if args[i] = u.bound[u.tparams.At(i)]; args[i] == nil {
+			// not inferable from the methods
+			return nil
+		}
+	}
+	inst, err := types.Instantiate(nil, named, args, true)
+	if err != nil {
+		// the type arguments don't satisfy the constraints
+		return nil
+	}
+	return inst
+}
+
+// unifier infers the type arguments of a generic type by matching the
+// types built from its type parameters against concrete ones.
+type unifier struct {
+	tparams *types.TypeParamList
+	bound   map[*types.TypeParam]types.Type
+}
+
+func (u *unifier) isParam(tp *types.TypeParam) bool {
+	for i := 0; i < u.tparams.Len(); i++ {
+		if u.tparams.At(i) == tp {
+			return true
+		}
+	}
+	return false
+}
+
+// unify reports whether generic can be turned into concrete by replacing
+// its type parameters, binding them as it goes.
+func (u *unifier) unify(generic, concrete types.Type) bool {
+	switch x := generic.(type) {
+	case *types.TypeParam:
+		if !u.isParam(x) {
+			break
+		}
+		if prev := u.bound[x]; prev != nil {
+			return types.Identical(prev, concrete)
+		}
+		u.bound[x] = concrete
+		return true
+	case *types.Pointer:
+		y, ok := concrete.(*types.Pointer)
+		return ok && u.unify(x.Elem(), y.Elem())
+	case *types.Slice:
+		y, ok := concrete.(*types.Slice)
+		return ok && u.unify(x.Elem(), y.Elem())
+	case *types.Array:
+		y, ok := concrete.(*types.Array)
+		return ok && x.Len() == y.Len() && u.unify(x.Elem(), y.Elem())
+	case *types.Map:
+		y, ok := concrete.(*types.Map)
+		return ok && u.unify(x.Key(), y.Key()) && u.unify(x.Elem(), y.Elem())
+	case *types.Chan:
+		y, ok := concrete.(*types.Chan)
+		return ok && x.Dir() == y.Dir() && u.unify(x.Elem(), y.Elem())
+	case *types.Signature:
+		y, ok := concrete.(*types.Signature)
+		return ok && x.Variadic() == y.Variadic() &&
+			u.unify(x.Params(), y.Params()) && u.unify(x.Results(), y.Results())
+	case *types.Tuple:
+		y, ok := concrete.(*types.Tuple)
+		if !ok || x.Len() != y.Len() {
+			return false
+		}
+		for i := 0; i < x.Len(); i++ {
+			if !u.unify(x.At(i).Type(), y.At(i).Type()) {
+				return false
+			}
+		}
+		return true
+	case *types.Named:
+		y, ok := concrete.(*types.Named)
+		if !ok || x.TypeArgs().Len() == 0 {
+			break
+		}
+		if x.Origin() != y.Origin() || x.TypeArgs().Len() != y.TypeArgs().Len() {
+			return false
+		}
+		for i := 0; i < x.TypeArgs().Len(); i++ {
+			if !u.unify(x.TypeArgs().At(i), y.TypeArgs().At(i)) {
+				return false
+			}
+		}
+		return true
+	}
+	return types.Identical(generic, concrete)
+}
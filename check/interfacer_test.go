@@ -9,40 +9,46 @@ import (
 	"go/build"
 	"go/parser"
 	"go/token"
-	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"reflect"
 	"regexp"
 	"strings"
 	"testing"
 
-	"github.com/kisielk/gotool"
+	"golang.org/x/tools/go/analysis/analysistest"
+	"golang.org/x/tools/go/packages"
 )
 
 const testdata = "testdata"
 
 var (
 	issuesRe = regexp.MustCompile(`^WARN (.*)\n?$`)
-	singleRe = regexp.MustCompile(`([^ ]*) can be ([^ ]*)(,|$)`)
+	// type arguments may contain spaces, as in Pair[string, int]
+	singleRe = regexp.MustCompile(`([^ ]*) can be ((?:[^ \[]|\[(?:[^\[\]]|\[[^\[\]]*\])*\])*)( \(superset match\))?( \(new imports? [^)]*\))?(,|$)`)
 )
 
 func goFiles(t *testing.T, p string) []string {
 	if strings.HasSuffix(p, ".go") {
 		return []string{p}
 	}
-	dirs := gotool.ImportPaths([]string{p})
+	cfg := &packages.Config{Mode: packages.NeedFiles}
+	pkgs, err := packages.Load(cfg, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
 	var paths []string
-	for _, dir := range dirs {
-		files, err := ioutil.ReadDir(dir)
-		if err != nil {
-			t.Fatal(err)
-		}
-		for _, file := range files {
-			if file.IsDir() {
-				continue
+	for _, pkg := range pkgs {
+		for _, path := range pkg.GoFiles {
+			if rel, err := filepath.Rel(wd, path); err == nil {
+				path = rel
 			}
-			paths = append(paths, filepath.Join(dir, file.Name()))
+			paths = append(paths, path)
 		}
 	}
 	return paths
@@ -95,7 +101,7 @@ func wantedIssues(t *testing.T, p string) []string {
 				continue
 			}
 			for _, m := range singleRe.FindAllStringSubmatch(cm[1], -1) {
-				vname, tname := m[1], m[2]
+				vname, tname := m[1], m[2]+m[3]+m[4]
 				line := fset.Position(group.Pos()).Line
 				pos := fset.Position(identPos[identKey(line, vname)])
 				lines = append(lines, fmt.Sprintf("%s: %s can be %s",
@@ -125,13 +131,8 @@ func doTestLines(t *testing.T, name string, want []string, args ...string) {
 }
 
 func doTestString(t *testing.T, name, want string, args ...string) {
-	switch len(args) {
-	case 0:
+	if len(args) == 0 {
 		args = []string{name}
-	case 1:
-		if args[0] == "" {
-			args = nil
-		}
 	}
 	issues, err := CheckArgs(args)
 	if err != nil {
@@ -193,7 +194,10 @@ func runLocalTests(t *testing.T, paths ...string) {
 	}
 	// non-recursive
 	doTest(t, "./single")
-	doTestString(t, "no-args", "", "")
+	// no args, and no Go files in the current directory
+	if _, err := CheckArgs(nil); err == nil || !strings.Contains(err.Error(), "no Go files") {
+		t.Fatalf("Expected a no Go files error, got: %v", err)
+	}
 }
 
 func runNonlocalTests(t *testing.T, paths ...string) {
@@ -214,7 +218,7 @@ func runNonlocalTests(t *testing.T, paths ...string) {
 	// non-recursive
 	doTest(t, "single")
 	// make sure we don't miss a package's imports
-	doTestString(t, "grab-import", "grab-import/use.go:27:15: s can be grab-import/def/nested.Fooer")
+	doTestString(t, "grab-import", "grab-import/use.go:27:15: s can be def2.Fooer")
 	defer chdirUndo(t, "nested/pkg")()
 	// relative paths
 	doTestString(t, "rel-path", "simple.go:12:17: rc can be Closer", "./...")
@@ -228,8 +232,11 @@ func TestMain(m *testing.M) {
 	if err != nil {
 		panic(err)
 	}
-	build.Default.GOPATH = wd
-	gotool.DefaultContext.BuildContext.GOPATH = wd
+	// The src and local trees are laid out in GOPATH mode, while mod
+	// and work opt back into modules.
+	os.Setenv("GOPATH", wd)
+	os.Setenv("GO111MODULE", "off")
+	os.Setenv("GOFLAGS", "")
 	os.Exit(m.Run())
 }
 
@@ -239,6 +246,68 @@ func TestIssues(t *testing.T) {
 	runNonlocalTests(t)
 }
 
+func runModuleTests(t *testing.T) {
+	t.Setenv("GO111MODULE", "on")
+	t.Setenv("GOPROXY", "off")
+	t.Setenv("GOWORK", "off")
+	func() {
+		// replace directive pointing at a sibling module
+		defer chdirUndo(t, filepath.Join("mod", "app"))()
+		doTest(t, "./...")
+	}()
+	func() {
+		// go.work with multiple modules
+		defer chdirUndo(t, "work")()
+		t.Setenv("GOWORK", "")
+		doTest(t, "example.com/main/...")
+	}()
+}
+
+func TestModules(t *testing.T) {
+	runModuleTests(t)
+}
+
+func TestAnalyzer(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	analysistest.Run(t, filepath.Join(wd, "analysis"), Analyzer, "single")
+}
+
+func TestVetTool(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the binary and runs go vet")
+	}
+	t.Setenv("GO111MODULE", "on")
+	t.Setenv("GOWORK", "off")
+	bin := filepath.Join(t.TempDir(), "interfacer")
+	cmd := exec.Command("go", "build", "-o", bin, ".")
+	cmd.Dir = filepath.Join("..", "..")
+	// the original GOPATH, for the module cache
+	cmd.Env = append(os.Environ(), "GOPATH="+build.Default.GOPATH)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go build failed: %v\n%s", err, out)
+	}
+	// the dependencies come from export data, which lacks the
+	// interface used only indirectly
+	vet := exec.Command("go", "vet", "-vettool="+bin, "./...")
+	vet.Dir = "vet"
+	vet.Env = append(os.Environ(), "GOPROXY=off")
+	out, _ := vet.CombinedOutput()
+	var got []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line != "" && !strings.HasPrefix(line, "#") {
+			got = append(got, line)
+		}
+	}
+	want := []string{`use/use.go:5:11: h can be ifaces.Closer (new import "example.com/vet/ifaces")`}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("Output mismatch in vet:\nwant:\n%s\ngot:\n%s",
+			strings.Join(want, "\n"), out)
+	}
+}
+
 func TestExtraArg(t *testing.T) {
 	_, err := CheckArgs([]string{"single", "--", "foo", "bar"})
 	got := err.Error()
@@ -247,3 +316,279 @@ func TestExtraArg(t *testing.T) {
 		t.Fatalf("Error mismatch:\nExpected:\n%s\nGot:\n%s", want, got)
 	}
 }
+
+func TestLoadErrors(t *testing.T) {
+	_, err := CheckArgs([]string{"./doesnotexist"})
+	if err == nil || !strings.Contains(err.Error(), "doesnotexist") {
+		t.Fatalf("Expected an error about the missing package, got: %v", err)
+	}
+}
+
+func TestVendoredTypes(t *testing.T) {
+	defer chdirUndo(t, "src")()
+	// the vendored codec package and the one at the top of GOPATH
+	// have types that print the same
+	issues, err := Check(Options{Patterns: []string{"vendorsig"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.Func.Name()+" "+issue.NewTypePath)
+	}
+	if want := []string{"Run vendorsig/vendor/codec"}; !reflect.DeepEqual(want, got) {
+		t.Fatalf("Issues mismatch:\nExpected:\n%v\nGot:\n%v", want, got)
+	}
+}
+
+func TestIssueFields(t *testing.T) {
+	issues, err := Check(Options{Patterns: []string{"single"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) == 0 {
+		t.Fatal("Expected issues in single")
+	}
+	issue := issues[0]
+	got := fmt.Sprintf("%s %s %s %s %s %d:%d-%d:%d",
+		issue.Func.Name(), issue.Param.Name(), issue.OldType,
+		issue.NewType, issue.NewTypePath,
+		issue.Position.Line, issue.Position.Column,
+		issue.EndPosition.Line, issue.EndPosition.Column)
+	want := "BasicWrong rc single.ReadCloser single.Closer single 19:17-19:19"
+	if got != want {
+		t.Fatalf("Issue mismatch:\nExpected:\n%s\nGot:\n%s", want, got)
+	}
+	if got, want := issue.String(), issue.Position.String()+": rc can be Closer"; got != want {
+		t.Fatalf("String mismatch:\nExpected:\n%s\nGot:\n%s", want, got)
+	}
+}
+
+func TestFix(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("src", "fix", "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doTestFix(t, Options{Patterns: []string{"fix"}}, paths...)
+	// an import can't take a name declared in another file
+	doTestFix(t, Options{Patterns: []string{"fixclash"}}, filepath.Join("src", "fixclash", "a.go"))
+}
+
+func TestVerify(t *testing.T) {
+	opts := Options{Patterns: []string{"verify/..."}, Verify: true}
+	issues, err := Check(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.Func.Name())
+	}
+	if want := []string{"Valid", "Chain", "shut"}; !reflect.DeepEqual(want, got) {
+		t.Fatalf("Verified funcs mismatch:\nExpected:\n%v\nGot:\n%v", want, got)
+	}
+	opts.KeepRejected = true
+	issues, err = Check(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = got[:0]
+	for _, issue := range issues {
+		got = append(got, fmt.Sprintf("%s %t", issue.Func.Name(), issue.Rejected != nil))
+	}
+	want := []string{"Handle true", "Valid false", "Chain false", "shut false"}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("Rejected funcs mismatch:\nExpected:\n%v\nGot:\n%v", want, got)
+	}
+}
+
+func TestSuperset(t *testing.T) {
+	opts := Options{
+		Patterns: []string{"./superset"},
+		Superset: true,
+	}
+	doTestOpts(t, "superset", `superset/superset.go:36:12: f can be AB (superset match)
+superset/superset.go:40:12: f can be AC (superset match)
+superset/superset.go:44:19: x can be AB (superset match)
+superset/superset.go:52:16: f can be AB
+superset/superset.go:62:15: t can be testing.TB (superset match)
+superset/superset.go:69:14: call can be ast.Node (superset match)`, opts)
+}
+
+func TestHazards(t *testing.T) {
+	opts := Options{
+		Patterns:    []string{"./hazards"},
+		KeepHazards: true,
+	}
+	doTestOpts(t, "hazards", `hazards/hazards.go:10:15: f can be io.Closer (hazard: typed-nil)
+hazards/hazards.go:16:23: f can be io.Closer (hazard: typed-nil)
+hazards/hazards.go:24:21: f can be io.Closer (hazard: typed-nil)
+hazards/hazards.go:32:21: f can be io.Closer (hazard: typed-nil)
+hazards/hazards.go:37:19: f can be io.Closer (hazard: typed-nil)
+hazards/hazards.go:43:13: f can be io.Closer (hazard: incomparable)
+hazards/hazards.go:48:19: f can be io.Closer (hazard: incomparable)
+hazards/hazards.go:53:19: f can be io.Closer (hazard: incomparable)
+hazards/hazards.go:58:20: f can be io.Closer (hazard: incomparable)
+hazards/hazards.go:58:23: f2 can be io.Closer (hazard: incomparable)
+hazards/hazards.go:64:22: f can be io.Closer
+hazards/hazards.go:69:12: rc can be io.Closer`, opts)
+	opts.KeepHazards = false
+	doTestOpts(t, "hazards", `hazards/hazards.go:64:22: f can be io.Closer
+hazards/hazards.go:69:12: rc can be io.Closer`, opts)
+}
+
+func TestFastPaths(t *testing.T) {
+	opts := Options{Patterns: []string{"./fastpath"}}
+	doTestOpts(t, "fastpath", `fastpath/fastpath.go:8:24: f can be io.Reader (hides io.WriterTo)
+fastpath/fastpath.go:27:12: b can be io.Writer (hides Flusher)
+fastpath/fastpath.go:37:18: b can be io.Writer (hides Flusher)
+fastpath/fastpath.go:46:12: p can be io.Closer
+fastpath/fastpath.go:50:19: b can be io.Writer`, opts)
+}
+
+func TestTests(t *testing.T) {
+	opts := Options{Patterns: []string{"tests", "fieldtests"}, Tests: true, Superset: true}
+	doTestOpts(t, "tests", `src/fieldtests/fieldtests.go:7:2: f can be fs.File (superset match) (new import "io/fs")
+src/tests/external_test.go:10:29: rc can be io.Reader
+src/tests/tests.go:8:11: f can be io.Reader
+src/tests/tests.go:25:2: f can be fs.File (superset match) (new import "io/fs")
+src/tests/tests_test.go:10:16: t can be testing.TB (superset match)`, opts)
+}
+
+func TestConfigErrors(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{"ignore-types = [\"*os.File\"]\nfoo = 1", `: unknown setting "foo"`},
+		{"[section]\nfoo = 1", `: unknown setting "section"`},
+		{"a.b = true", `: unknown setting "a"`},
+		{"import-depth = 1.5", ": import-depth must be a non-negative integer"},
+		{"import-depth = -1", ": import-depth must be a non-negative integer"},
+		{`mention-name = "yes"`, ": mention-name must be true or false"},
+		{`ignore-types = "*os.File"`, ": ignore-types must be an array of strings"},
+		{"ignore-types = [1]", ": ignore-types must be an array of strings"},
+		{`ignore-types = ["\x41"]`, `:1: invalid escape in string '\x'`},
+		{`ignore-types = "a`, `:1: unexpected EOF; expected '"'`},
+		{"mention-name = true\nmention-name = false", ":2: Key 'mention-name' has already been defined."},
+		{"exported-only = false\n\nmention-name true", ":3: expected '.' or '=', but got 't' instead"},
+	}
+	path := filepath.Join(t.TempDir(), configName)
+	for _, tc := range tests {
+		if err := os.WriteFile(path, []byte(tc.src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		err := defaultConfig().decodeFile(path)
+		if err == nil || err.Error() != path+tc.want {
+			t.Errorf("Error mismatch for %q:\nExpected:\n%s\nGot:\n%v",
+				tc.src, path+tc.want, err)
+		}
+	}
+}
+
+func TestDirectives(t *testing.T) {
+	opts := Options{Patterns: []string{"./directives"}}
+	doTestOpts(t, "directives", `directives/clean.go:1:1: unused //interfacer:ignore-file directive
+directives/directives.go:14:22: b can be io.Closer
+directives/directives.go:24:1: unused //interfacer:ignore directive
+directives/directives.go:29:1: unused //interfacer:ignore directive for missing
+directives/directives.go:30:20: b can be io.Closer
+directives/directives.go:42:11: f can be io.Reader`, opts)
+}
+
+func TestTypeParams(t *testing.T) {
+	opts := Options{Patterns: []string{"./typeparams"}}
+	doTestOpts(t, "typeparams", `typeparams/typeparams.go:9:12: R can be constrained by io.Reader
+typeparams/typeparams.go:18:15: R can be constrained by io.Closer
+typeparams/typeparams.go:23:13: R can be constrained by io.Reader
+typeparams/typeparams.go:31:19: R can be constrained by io.Closer
+typeparams/typeparams.go:39:15: R can be constrained by io.Closer
+typeparams/typeparams.go:64:14: S can be constrained by fmt.Stringer
+typeparams/typeparams.go:68:12: R can be constrained by io.Closer
+typeparams/typeparams.go:80:15: b can be Stringer`, opts)
+	doTestFix(t, opts, filepath.Join("typeparams", "typeparams.go"))
+}
+
+func doTestOpts(t *testing.T, name, want string, opts Options) {
+	issues, err := Check(opts)
+	if err != nil {
+		t.Fatalf("Did not want error in %s:\n%v", name, err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := make([]string, len(issues))
+	for i, issue := range issues {
+		lines[i] = issue.Format(wd)
+	}
+	if got := strings.Join(lines, "\n"); want != got {
+		t.Fatalf("Output mismatch in %s:\nExpected:\n%s\nGot:\n%s",
+			name, want, got)
+	}
+}
+
+// doTestFix checks that fixing the issues found with opts turns each of
+// the files at paths into its ".golden" counterpart. It returns all the
+// fixed files.
+func doTestFix(t *testing.T, opts Options, paths ...string) map[string][]byte {
+	issues, err := Check(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixed, err := Fix(issues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := os.ReadFile(path + ".golden")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fixed[abs]; string(got) != string(want) {
+			t.Errorf("Fix mismatch in %s:\nExpected:\n%s\nGot:\n%s",
+				path, want, got)
+		}
+	}
+	return fixed
+}
+
+func TestSynthesize(t *testing.T) {
+	opts := Options{
+		Patterns:   []string{"./synth"},
+		Synthesize: true,
+	}
+	doTestOpts(t, "synth", `synth/synth.go:5:15: f could use an interface with methods Read, Stat
+synth/synth.go:13:19: f could use an interface with methods Read, Stat
+synth/synth.go:23:16: r could use an interface with methods Close, Open
+synth/synth.go:28:12: f can be io.Closer (new import "io")`, opts)
+	doTestFix(t, opts, filepath.Join("synth", "synth.go"), filepath.Join("synth", "interfaces.go"))
+}
+
+func TestIfaceMethods(t *testing.T) {
+	opts := Options{
+		Patterns:     []string{"./ifaces"},
+		IfaceMethods: true,
+		Verify:       true,
+	}
+	doTestOpts(t, "ifaces", `ifaces/ifaces.go:6:10: f can be io.Reader (implementations: A.Process, (*B).Process) (new import "io")
+ifaces/ifaces.go:25:9: parameter 1 can be io.Closer (implementations: C.Handle) (new import "io")`, opts)
+	path := filepath.Join("ifaces", "ifaces.go")
+	fixed := doTestFix(t, opts, path)
+	if len(fixed) != 1 {
+		t.Errorf("Expected only %s to be fixed, got %d files", path, len(fixed))
+	}
+}
+
+func TestIfaceMethodsImports(t *testing.T) {
+	defer chdirUndo(t, "src")()
+	// the interface and its implementation are in different packages,
+	// and the former imports io with another name
+	doTestOpts(t, "ifacepkgs", `ifacepkgs/a/a.go:9:10: f can be myio.Reader (implementations: ifacepkgs/b.B.Process)`, Options{
+		Patterns:     []string{"ifacepkgs/..."},
+		IfaceMethods: true,
+	})
+}
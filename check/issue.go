@@ -0,0 +1,285 @@
+// Copyright (c) 2015, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package check
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Hazard is a way in which a suggestion could change the behavior of
+// the code at run time, despite it still compiling.
+type Hazard string
+
+const (
+	// HazardTypedNil means that the parameter is compared with nil,
+	// which a nil pointer passed as an interface is not equal to.
+	HazardTypedNil Hazard = "typed-nil"
+	// HazardIncomparable means that the parameter is compared with
+	// an interface or used as a map key, which panics if the value
+	// passed has a type that isn't comparable.
+	HazardIncomparable Hazard = "incomparable"
+)
+
+// Issue describes a parameter whose type could be replaced by a less
+// specific interface type.
+type Issue struct {
+	// Func is the function or method declaring the parameter. It is
+	// nil for struct fields.
+	Func *types.Func
+	// Struct is the struct type declaring the field, when the issue
+	// is about a struct field instead of a parameter.
+	Struct *types.TypeName
+	// Param is the parameter or struct field itself. It is nil for
+	// type parameters.
+	Param *types.Var
+	// TypeParam is the type parameter of Func whose constraint could
+	// be NewType, when the issue is about a type parameter.
+	TypeParam *types.TypeParam
+	// OldType is the type the parameter is declared with.
+	OldType types.Type
+	// NewType is the suggested interface type.
+	NewType types.Type
+	// NewTypePath is the import path of the package declaring
+	// NewType.
+	NewTypePath string
+	// NewImports are the import paths of the packages that NewType
+	// refers to and that the file declaring the parameter doesn't
+	// import yet, so that applying the suggestion would add them.
+	NewImports []string
+	// Superset is true if NewType has more methods than the ones
+	// used, as no interface matched them exactly.
+	Superset bool
+	// Synthesized is true if NewType is a new interface, which
+	// would have to be declared in the package of Func.
+	Synthesized bool
+
+	// Implementations are the methods implementing Func when it is
+	// an interface method. They would all need the same change, to
+	// their parameter at ParamIndex.
+	Implementations []*types.Func
+	ParamIndex      int
+
+	// Rejected is the type error that applying the suggestion would
+	// introduce. It is only set when verifying suggestions.
+	Rejected error
+
+	// Hazards are the ways in which the suggestion could change the
+	// behavior at run time. Such issues are only kept when asked
+	// for, via Options.KeepHazards.
+	Hazards []Hazard
+
+	// FastPaths are the optional interfaces that OldType implements
+	// and NewType doesn't, which the funcs that the parameter is
+	// passed to check for via type assertions.
+	FastPaths []types.Type
+
+	// Directive is set for an ignore directive that suppressed no
+	// issues, such as "interfacer:ignore f", instead of all the fields
+	// above. Position and EndPosition then span the comment.
+	Directive string
+
+	// Position and EndPosition span the parameter name.
+	Position    token.Position
+	EndPosition token.Position
+
+	pos, end  token.Pos
+	paramName string
+	newName   string
+
+	implPositions []token.Position
+
+	// params in the same package that must be narrowed too
+	needs []token.Pos
+}
+
+// positions returns the positions of all the parameters that would
+// change with the issue.
+func (i Issue) positions() []token.Position {
+	return append([]token.Position{i.Position}, i.implPositions...)
+}
+
+// pkg returns the package declaring the parameter or field.
+func (i Issue) pkg() *types.Package {
+	if i.Param == nil {
+		return i.Func.Pkg()
+	}
+	return i.Param.Pkg()
+}
+
+// Pos returns the position of the parameter name.
+func (i Issue) Pos() token.Pos { return i.pos }
+
+// End returns the position just after the parameter name.
+func (i Issue) End() token.Pos { return i.end }
+
+// Message returns a short human-readable description of the issue,
+// such as "rc can be io.Reader".
+func (i Issue) Message() string {
+	if i.Directive != "" {
+		return i.directiveMessage()
+	}
+	msg := fmt.Sprintf("%s can be %s", i.paramName, i.newName)
+	if i.TypeParam != nil {
+		msg = fmt.Sprintf("%s can be constrained by %s", i.paramName, i.newName)
+	}
+	if i.Synthesized {
+		iface := i.NewType.Underlying().(*types.Interface)
+		fnames := make([]string, iface.NumMethods())
+		for j := range fnames {
+			fnames[j] = iface.Method(j).Name()
+		}
+		msg = fmt.Sprintf("%s could use an interface with methods %s",
+			i.paramName, strings.Join(fnames, ", "))
+	}
+	if len(i.Implementations) > 0 {
+		names := make([]string, len(i.Implementations))
+		for j, fn := range i.Implementations {
+			names[j] = methodName(fn, i.pkg())
+		}
+		msg += fmt.Sprintf(" (implementations: %s)", strings.Join(names, ", "))
+	}
+	if i.Superset {
+		msg += " (superset match)"
+	}
+	switch len(i.NewImports) {
+	case 0:
+	case 1:
+		msg += fmt.Sprintf(" (new import %q)", i.NewImports[0])
+	default:
+		quoted := make([]string, len(i.NewImports))
+		for j, path := range i.NewImports {
+			quoted[j] = strconv.Quote(path)
+		}
+		msg += fmt.Sprintf(" (new imports %s)", strings.Join(quoted, ", "))
+	}
+	for _, h := range i.Hazards {
+		msg += fmt.Sprintf(" (hazard: %s)", h)
+	}
+	if len(i.FastPaths) > 0 {
+		names := make([]string, len(i.FastPaths))
+		for j, t := range i.FastPaths {
+			names[j] = types.TypeString(t, func(pkg *types.Package) string {
+				if pkg == i.pkg() {
+					return ""
+				}
+				return pkg.Path()
+			})
+		}
+		msg += fmt.Sprintf(" (hides %s)", strings.Join(names, ", "))
+	}
+	if i.Rejected != nil {
+		msg += fmt.Sprintf(" (rejected: %v)", i.Rejected)
+	}
+	return msg
+}
+
+// String formats the issue as "file:line:col: message".
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s", i.Position, i.Message())
+}
+
+// Format is like String, but makes the filename relative to dir if the
+// file is within it.
+func (i Issue) Format(dir string) string {
+	pos := i.Position
+	if rel, err := filepath.Rel(dir, pos.Filename); err == nil &&
+		!strings.HasPrefix(rel, "..") {
+		pos.Filename = rel
+	}
+	return fmt.Sprintf("%s: %s", pos, i.Message())
+}
+
+func (c *Checker) newIssue(param *types.Var, sugg *suggestion) Issue {
+	tn := sugg.tn
+	typ := sugg.typ
+	if typ == nil {
+		typ = tn.Type()
+	}
+	name, newImports := c.fileTypeString(typ, param.Pos())
+	end := param.Pos() + token.Pos(len(param.Name()))
+	return Issue{
+		Param:       param,
+		OldType:     param.Type(),
+		NewType:     typ,
+		NewTypePath: tn.Pkg().Path(),
+		NewImports:  newImports,
+		Superset:    sugg.superset,
+		Synthesized: sugg.synthesized,
+		Hazards:     sugg.hazards,
+		Position:    c.fset.Position(param.Pos()),
+		EndPosition: c.fset.Position(end),
+		pos:         param.Pos(),
+		end:         end,
+		paramName:   param.Name(),
+		newName:     name,
+	}
+}
+
+// fileTypeString formats t as it would be written in the file containing
+// pos, using the names that the file imports the packages with. The
+// packages that it doesn't import yet are written with their names, and
+// their import paths are returned.
+func (c *Checker) fileTypeString(t types.Type, pos token.Pos) (string, []string) {
+	var file *ast.File
+	for _, f := range c.files {
+		if f.FileStart <= pos && pos <= f.FileEnd {
+			file = f
+			break
+		}
+	}
+	var newImports []string
+	name := types.TypeString(t, func(pkg *types.Package) string {
+		if pkg == c.pkg {
+			return ""
+		}
+		ipath := importPath(pkg.Path())
+		if file != nil {
+			if name, ok := importedName(file, ipath, pkg.Name()); ok {
+				return name
+			}
+		}
+		if !containsName(newImports, ipath) {
+			newImports = append(newImports, ipath)
+		}
+		return pkg.Name()
+	})
+	return name, newImports
+}
+
+// importedName returns the name that file refers to the package at ipath
+// with, if it imports it.
+func importedName(file *ast.File, ipath, pkgName string) (string, bool) {
+	for _, imp := range file.Imports {
+		if p, _ := strconv.Unquote(imp.Path.Value); p != ipath {
+			continue
+		}
+		switch {
+		case imp.Name == nil:
+			return pkgName, true
+		case imp.Name.Name == "_":
+			continue
+		case imp.Name.Name == ".":
+			return "", true
+		}
+		return imp.Name.Name, true
+	}
+	return "", false
+}
+
+func sortIssues(issues []Issue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		pi, pj := issues[i].Position, issues[j].Position
+		if pi.Filename != pj.Filename {
+			return pi.Filename < pj.Filename
+		}
+		return pi.Offset < pj.Offset
+	})
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2015, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package check
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// synthesize returns a new interface type declared in the package being
+// checked, with the methods in called taken from t. The same type is
+// returned for the same set of methods within a package.
+//
+// The synthesized types and names are tracked per package, as
+// interface methods are checked after all packages.
+func (c *Checker) synthesize(t types.Type, called funcMap, exported bool) *suggestion {
+	if len(called) == 0 {
+		return nil
+	}
+	for _, named := range c.synthesized[c.pkg] {
+		if sameFuncs(typeFuncMap(named), called) {
+			return &suggestion{tn: named.Obj(), funcs: called, synthesized: true}
+		}
+	}
+	fnames := make([]string, 0, len(called))
+	for fname := range called {
+		fnames = append(fnames, fname)
+	}
+	sort.Strings(fnames)
+	methods := make([]*types.Func, len(fnames))
+	for i, fname := range fnames {
+		obj, _, _ := types.LookupFieldOrMethod(t, true, c.pkg, fname)
+		m, ok := obj.(*types.Func)
+		if !ok {
+			return nil
+		}
+		sign := m.Type().(*types.Signature)
+		sign = types.NewSignatureType(nil, nil, nil,
+			sign.Params(), sign.Results(), sign.Variadic())
+		methods[i] = types.NewFunc(token.NoPos, c.pkg, fname, sign)
+	}
+	name := interfaceName(fnames, exported)
+	taken := func(name string) bool {
+		return c.pkg.Scope().Lookup(name) != nil || c.synthNames[c.pkg.Path()+"."+name]
+	}
+	for i := 2; taken(name); i++ {
+		name = fmt.Sprintf("%s%d", interfaceName(fnames, exported), i)
+	}
+	c.synthNames[c.pkg.Path()+"."+name] = true
+	iface := types.NewInterfaceType(methods, nil).Complete()
+	named := types.NewNamed(types.NewTypeName(token.NoPos, c.pkg, name, nil), iface, nil)
+	c.synthesized[c.pkg] = append(c.synthesized[c.pkg], named)
+	return &suggestion{tn: named.Obj(), funcs: called, synthesized: true}
+}
+
+// interfaceName derives an interface name from its method names,
+// following the usual convention of io.ReadCloser.
+func interfaceName(fnames []string, exported bool) string {
+	var buf bytes.Buffer
+	for i, fname := range fnames {
+		if i == len(fnames)-1 {
+			if strings.HasSuffix(fname, "e") {
+				fname += "r"
+			} else {
+				fname += "er"
+			}
+		}
+		buf.WriteString(strings.ToUpper(fname[:1]) + fname[1:])
+	}
+	name := buf.String()
+	if !exported {
+		name = strings.ToLower(name[:1]) + name[1:]
+	}
+	return name
+}
+
+// declFile returns the name of the file in which to declare the
+// interface synthesized for issue.
+func declFile(issue Issue) string {
+	return filepath.Join(filepath.Dir(issue.Position.Filename), "interfaces.go")
+}
+
+// declText returns the source of the declaration of the interface
+// synthesized for issue, using ff to refer to other packages.
+func (ff *fileFixer) declText(issue Issue) string {
+	pkg := issue.pkg()
+	qual := func(p *types.Package) string {
+		if p == pkg {
+			return ""
+		}
+		return ff.importName(importPath(p.Path()), p.Name())
+	}
+	named := issue.NewType.(*types.Named)
+	iface := named.Underlying().(*types.Interface)
+	var buf bytes.Buffer
+	name := named.Obj().Name()
+	var user string
+	if issue.Struct != nil {
+		user = issue.Struct.Name() + "." + issue.Param.Name()
+	} else {
+		user = issue.Func.Name()
+	}
+	fmt.Fprintf(&buf, "// %s has the methods of %s used by %s.\n", name,
+		types.TypeString(issue.OldType, qual), user)
+	fmt.Fprintf(&buf, "type %s interface {\n", name)
+	for i := 0; i < iface.NumExplicitMethods(); i++ {
+		m := iface.ExplicitMethod(i)
+		buf.WriteString(m.Name())
+		types.WriteSignature(&buf, m.Type().(*types.Signature), qual)
+		buf.WriteString("\n")
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
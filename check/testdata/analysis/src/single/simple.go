@@ -0,0 +1,32 @@
+package single // want package:"interfaces\\(.* packages\\)"
+
+import "io"
+
+type Closer interface {
+	Close()
+}
+
+type ReadCloser interface {
+	Closer
+	Read()
+}
+
+func Basic(c Closer) {
+	c.Close()
+}
+
+func BasicWrong(rc ReadCloser) { // want `rc can be Closer`
+	rc.Close()
+}
+
+func ImportWrong(rc io.ReadCloser) { // want `rc can be io.Closer`
+	rc.Close()
+}
+
+type St struct{}
+
+func (s St) Close() {}
+
+func OtherWrong(s St) { // want `s can be Closer`
+	s.Close()
+}
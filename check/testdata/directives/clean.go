@@ -0,0 +1,3 @@
+//interfacer:ignore-file // nothing to ignore here
+
+package directives
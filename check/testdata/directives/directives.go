@@ -0,0 +1,59 @@
+package directives
+
+import (
+	"io"
+	"os"
+)
+
+//interfacer:ignore // part of the public API
+func Ignored(rc io.ReadCloser) {
+	rc.Close()
+}
+
+//interfacer:ignore a
+func IgnoredParam(a, b io.ReadCloser) {
+	a.Close()
+	b.Close()
+}
+
+//interfacer:ignore R
+func IgnoredTypeParam[R io.ReadCloser](r R) {
+	r.Close()
+}
+
+//interfacer:ignore
+func NothingToIgnore(r io.Reader) {
+	r.Read(nil)
+}
+
+//interfacer:ignore a, missing
+func StaleParam(a, b io.ReadCloser) {
+	a.Close()
+	b.Close()
+}
+
+// Open opens a file.
+//
+//interfacer:ignore f
+func Open(f *os.File) {
+	read(f)
+}
+
+func read(f *os.File) {
+	f.Read(nil)
+}
+
+type service struct {
+	//interfacer:ignore // keeps the file's name around
+	f *os.File
+	w *os.File //interfacer:ignore
+}
+
+func newService(f, w *os.File) *service {
+	return &service{f: f, w: w}
+}
+
+func (s *service) close() {
+	s.f.Close()
+	s.w.Write(nil)
+}
@@ -0,0 +1,27 @@
+package foo
+
+type Bytes = []byte
+
+type Count = int
+
+type Reader interface {
+	Read([]byte) (int, error)
+}
+
+type ByteSource interface {
+	Read(Bytes) (Count, error)
+	Close() error
+}
+
+func ReadAlias(s ByteSource) { // WARN s can be Reader
+	s.Read(nil)
+}
+
+type aliasedReader struct{}
+
+func (r *aliasedReader) Read(p Bytes) (n Count, err error) { return 0, nil }
+func (r *aliasedReader) Close() error                      { return nil }
+
+func ReadConcrete(r *aliasedReader) { // WARN r can be Reader
+	r.Read(nil)
+}
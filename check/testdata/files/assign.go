@@ -15,3 +15,29 @@ func Transitive(rc ReadCloser) { // WARN rc can be Closer
 	c := b
 	c.Close()
 }
+
+func VarNoType(rc ReadCloser) { // WARN rc can be Closer
+	var a = rc
+	a.Close()
+}
+
+func VarNoTypeUsed(rc ReadCloser) {
+	var a = rc
+	a.Close()
+	a.Read()
+}
+
+type mint int
+
+func (m mint) Close() {}
+
+func OpAssign(m mint) {
+	m.Close()
+	m += 2
+}
+
+func OpAssignRight(m mint) {
+	m.Close()
+	var n mint
+	n += m
+}
@@ -0,0 +1,37 @@
+package foo
+
+type Closer interface {
+	Close()
+}
+
+type ReadCloser interface {
+	Closer
+	Read()
+}
+
+type st struct{}
+
+func (s *st) Close() {}
+
+func SendIface(rc ReadCloser, ch chan Closer) { // WARN rc can be Closer
+	rc.Close()
+	ch <- rc
+}
+
+func SendSame(rc ReadCloser, ch chan<- ReadCloser) {
+	rc.Close()
+	ch <- rc
+}
+
+func SendConcrete(s *st, ch chan *st) {
+	s.Close()
+	ch <- s
+}
+
+func SelectSend(s *st, ch chan *st) {
+	s.Close()
+	select {
+	case ch <- s:
+	default:
+	}
+}
@@ -84,3 +84,23 @@ func WrongWalkValue(fb FooBarer) { // WARN fb can be Fooer
 	fn := func(f Fooer) Fooer { return f }
 	_ = []Fooer{fn(fb)}
 }
+
+func MapKeyIface(fb FooBarer) { // WARN fb can be Fooer
+	_ = map[Fooer]string{
+		fb: "foo",
+	}
+}
+
+func MapValueIface(fb FooBarer) { // WARN fb can be Fooer
+	_ = map[string]Fooer{
+		"foo": fb,
+	}
+}
+
+func SliceKey(fb FooBarer) { // WARN fb can be Fooer
+	_ = []Fooer{2: fb}
+}
+
+func SliceKeySame(fb FooBarer) {
+	_ = []FooBarer{2: fb}
+}
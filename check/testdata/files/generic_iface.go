@@ -0,0 +1,85 @@
+package foo
+
+type Getter[T any] interface {
+	Get() T
+}
+
+type Pair[K comparable, V any] interface {
+	Key() K
+	Value() V
+}
+
+type Appender[T any] interface {
+	Append(xs ...T) []T
+}
+
+type Sized[T any] interface {
+	Size() int
+}
+
+type Store[T any] struct {
+	v T
+}
+
+func (s *Store[T]) Get() T       { return s.v }
+func (s *Store[T]) Set(v T)      { s.v = v }
+func (s *Store[T]) Size() int    { return 1 }
+func (s *Store[T]) Close() error { return nil }
+
+func GetOne(s *Store[string]) string { // WARN s can be Getter[string]
+	return s.Get()
+}
+
+func GetSlice(s *Store[[]*int]) { // WARN s can be Getter[[]*int]
+	s.Get()
+}
+
+func GetSet(s *Store[string]) {
+	s.Set(s.Get())
+}
+
+func SizeOnly(s *Store[int]) {
+	s.Size()
+}
+
+type entry struct{}
+
+func (e entry) Key() string  { return "" }
+func (e entry) Value() []int { return nil }
+func (e entry) Close() error { return nil }
+
+func KeyValue(e entry) { // WARN e can be Pair[string, []int]
+	e.Key()
+	e.Value()
+}
+
+type list struct{}
+
+func (l *list) Append(xs ...int) []int { return xs }
+func (l *list) Close() error           { return nil }
+
+func AppendTwo(l *list) { // WARN l can be Appender[int]
+	l.Append(1, 2)
+}
+
+type badList struct{}
+
+func (l *badList) Append(xs ...int) []string { return nil }
+func (l *badList) Close() error              { return nil }
+
+func AppendWrong(l *badList) {
+	l.Append(1, 2)
+}
+
+type Ordered[T interface{ ~int | ~string }] interface {
+	Min() T
+}
+
+type floats struct{}
+
+func (f floats) Min() float64 { return 0 }
+func (f floats) Close() error { return nil }
+
+func MinFloat(f floats) {
+	f.Min()
+}
@@ -0,0 +1,41 @@
+package foo
+
+type Closer interface {
+	Close()
+}
+
+type ReadCloser interface {
+	Closer
+	Read()
+}
+
+type st struct{}
+
+func (s *st) Close() {}
+
+func NilCheck(s *st) {
+	if s != nil {
+		s.Close()
+	}
+}
+
+func NilCheckIface(rc ReadCloser) { // WARN rc can be Closer
+	if rc != nil {
+		rc.Close()
+	}
+}
+
+func CompareIface(s *st, c Closer) {
+	s.Close()
+	_ = s == c
+}
+
+func CompareConcrete(s *st) { // WARN s can be Closer
+	s.Close()
+	_ = s == &st{}
+}
+
+func MapKey(s *st, m map[Closer]bool) {
+	s.Close()
+	delete(m, s)
+}
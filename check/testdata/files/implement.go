@@ -11,7 +11,7 @@ type ReadCloser interface {
 
 type MyFunc func(rc ReadCloser, err error) bool
 
-func MyFuncImpl(rc ReadCloser, err error) bool {
+func MyFuncImpl(rc ReadCloser, err error) bool { // WARN rc can be Closer
 	rc.Close()
 	return false
 }
@@ -19,3 +19,73 @@ func MyFuncImpl(rc ReadCloser, err error) bool {
 func MyFuncWrong(rc ReadCloser, err error) { // WARN rc can be Closer
 	rc.Close()
 }
+
+func MyFuncAssigned(rc ReadCloser, err error) bool {
+	rc.Close()
+	return false
+}
+
+var _ MyFunc = MyFuncAssigned
+
+func MyFuncDefined(rc ReadCloser, err error) bool {
+	rc.Close()
+	return false
+}
+
+func useDefined() {
+	f := MyFuncDefined
+	f(nil, nil)
+}
+
+type user struct{}
+
+func (u user) Use(rc ReadCloser) {
+	rc.Close()
+}
+
+func (u user) Other(rc ReadCloser) { // WARN rc can be Closer
+	rc.Close()
+}
+
+func convert() {
+	var x interface{ Use(ReadCloser) } = user{}
+	x.Use(nil)
+}
+
+type promoted struct{}
+
+func (p *promoted) Use(rc ReadCloser) {
+	rc.Close()
+}
+
+type wrapper struct {
+	*promoted
+}
+
+func convertWrapper() {
+	var x interface{ Use(ReadCloser) } = wrapper{}
+	x.Use(nil)
+}
+
+type asserted struct{}
+
+func (a asserted) Use(rc ReadCloser) {
+	rc.Close()
+}
+
+func convertAssert() {
+	var x interface{} = asserted{}
+	if u, ok := x.(interface{ Use(ReadCloser) }); ok {
+		u.Use(nil)
+	}
+}
+
+type Applier interface {
+	Apply(rc ReadCloser)
+}
+
+type inScope struct{}
+
+func (i inScope) Apply(rc ReadCloser) {
+	rc.Close()
+}
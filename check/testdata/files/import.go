@@ -30,7 +30,7 @@ type Namer interface {
 	Name() string
 }
 
-func WalkFuncImpl(path string, info os.FileInfo, err error) error {
+func WalkFuncImpl(path string, info os.FileInfo, err error) error { // WARN info can be Namer
 	info.Name()
 	return nil
 }
@@ -0,0 +1,47 @@
+package foo
+
+type Closer interface {
+	Close()
+}
+
+type st struct{}
+
+func (s *st) Close() {}
+
+func MapKeyIface(s *st, m map[Closer]int) {
+	s.Close()
+	_ = m[s]
+}
+
+func MapKeyConcrete(s *st, m map[*st]int) {
+	s.Close()
+	_ = m[s]
+}
+
+func MapKeyDelete(s *st, m map[*st]int) {
+	s.Close()
+	delete(m, s)
+}
+
+type list []int
+
+func (l list) Close() {}
+
+func Indexed(l list) {
+	l.Close()
+	_ = l[0]
+}
+
+func Sliced(l list) {
+	l.Close()
+	_ = l[1:]
+}
+
+type pos int
+
+func (p pos) Close() {}
+
+func Index(p pos, s []int) {
+	p.Close()
+	_ = s[p]
+}
@@ -0,0 +1,71 @@
+package foo
+
+type Reader interface {
+	Read([]byte) (int, error)
+}
+
+type Closer interface {
+	Close() error
+}
+
+type ReadCloser interface {
+	Reader
+	Closer
+}
+
+type ReadWriteCloser interface {
+	ReadCloser
+	Write([]byte) (int, error)
+}
+
+type readerFunc func([]byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) { return f(p) }
+
+func useReader(r Reader) {}
+
+func MethodValue(rwc ReadWriteCloser) { // WARN rwc can be ReadCloser
+	rwc.Close()
+	read := rwc.Read
+	read(nil)
+}
+
+func MethodValueArg(rwc ReadWriteCloser) { // WARN rwc can be ReadCloser
+	rwc.Close()
+	useReader(readerFunc(rwc.Read))
+}
+
+func MethodValueOnly(rwc ReadWriteCloser) { // WARN rwc can be Reader
+	useReader(readerFunc(rwc.Read))
+}
+
+func MethodExpr(rwc ReadWriteCloser) { // WARN rwc can be ReadCloser
+	rwc.Close()
+	ReadCloser.Read(rwc, nil)
+}
+
+func MethodExprSame(rwc ReadWriteCloser) {
+	rwc.Close()
+	ReadWriteCloser.Write(rwc, nil)
+}
+
+type st struct{}
+
+func (s *st) Read([]byte) (int, error) { return 0, nil }
+func (s *st) Close() error             { return nil }
+
+func MethodExprConcrete(s *st) {
+	s.Close()
+	(*st).Read(s, nil)
+}
+
+type withFunc struct {
+	fn func()
+}
+
+func (w *withFunc) Close() error { return nil }
+
+func FuncField(w *withFunc) {
+	w.Close()
+	w.fn()
+}
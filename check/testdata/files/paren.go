@@ -0,0 +1,27 @@
+package foo
+
+type Closer interface {
+	Close()
+}
+
+type ReadCloser interface {
+	Closer
+	Read()
+}
+
+func Paren(rc ReadCloser) { // WARN rc can be Closer
+	(rc).Close()
+}
+
+func ParenAssign(rc ReadCloser) { // WARN rc can be Closer
+	var c Closer
+	c = (rc)
+	c.Close()
+}
+
+func ParenUsed(rc ReadCloser) {
+	rc.Close()
+	var rc2 ReadCloser
+	rc2 = (rc)
+	rc2.Read()
+}
@@ -0,0 +1,69 @@
+package foo
+
+type Closer interface {
+	Close()
+}
+
+type ReadCloser interface {
+	Read()
+	Close()
+}
+
+type File struct{}
+
+func (f *File) Read()  {}
+func (f *File) Close() {}
+func (f *File) Stat()  {}
+
+func Open(f *File) { // WARN f can be ReadCloser
+	f.Read()
+	parse(f)
+}
+
+func parse(f *File) { // WARN f can be Closer
+	f.Close()
+	readAll(f)
+}
+
+func readAll(f *File) { // WARN f can be Closer
+	f.Close()
+}
+
+func OpenStat(f *File) {
+	f.Close()
+	stat(f)
+}
+
+func stat(f *File) {
+	f.Stat()
+}
+
+func OpenKept(f *File) {
+	f.Close()
+	kept(f)
+}
+
+func kept(f *File) {
+	f.Close()
+	_ = *f
+}
+
+func Ping(f *File) { // WARN f can be Closer
+	f.Close()
+	pong(f)
+}
+
+func pong(f *File) { // WARN f can be Closer
+	Ping(f)
+}
+
+func OpenValue(f *File) {
+	f.Close()
+	asValue(f)
+}
+
+func asValue(f *File) {
+	f.Close()
+}
+
+var holder = asValue
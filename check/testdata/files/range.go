@@ -0,0 +1,35 @@
+package foo
+
+type Closer interface {
+	Close()
+}
+
+type list []int
+
+func (l list) Close() {}
+
+func Ranged(l list) {
+	l.Close()
+	for range l {
+	}
+}
+
+type ReadCloser interface {
+	Closer
+	Read()
+}
+
+func RangedValue(rcs []ReadCloser) {
+	for _, rc := range rcs {
+		rc.Close()
+	}
+}
+
+func RangeAssign(rc ReadCloser, cs []Closer) { // WARN rc can be Closer
+	rc.Close()
+	for _, c := range cs {
+		if c == rc {
+			break
+		}
+	}
+}
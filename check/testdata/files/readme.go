@@ -5,7 +5,7 @@ import (
 	"os"
 )
 
-func ProcessInput(f *os.File) error { // WARN f can be io.Reader
+func ProcessInput(f *os.File) error { // WARN f can be io.Reader (new import "io")
 	b, err := ioutil.ReadAll(f)
 	if err != nil {
 		return err
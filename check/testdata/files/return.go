@@ -0,0 +1,50 @@
+package foo
+
+type Closer interface {
+	Close()
+}
+
+type ReadCloser interface {
+	Closer
+	Read()
+}
+
+type st struct{}
+
+func (s *st) Close() {}
+
+func ReturnIface(rc ReadCloser) Closer { // WARN rc can be Closer
+	rc.Close()
+	return rc
+}
+
+func ReturnSame(rc ReadCloser) ReadCloser {
+	rc.Close()
+	return rc
+}
+
+func ReturnConcrete(s *st) *st {
+	s.Close()
+	return s
+}
+
+func ReturnMultiple(s *st) (*st, error) {
+	s.Close()
+	return s, nil
+}
+
+func ReturnLit(rc ReadCloser) { // WARN rc can be Closer
+	rc.Close()
+	f := func() Closer {
+		return rc
+	}
+	f()
+}
+
+func ReturnLitSame(rc ReadCloser) {
+	rc.Close()
+	f := func() ReadCloser {
+		return rc
+	}
+	f()
+}
@@ -0,0 +1,50 @@
+package foo
+
+import (
+	"go/ast"
+	"testing"
+)
+
+// more exported methods than used, but only types like *testing.T can
+// implement it
+func checkSum(t *testing.T, got, want int) { // WARN t can be testing.TB (superset match)
+	t.Helper()
+	t.Cleanup(func() {})
+	if got != want {
+		t.Fatalf("got %d, want %d", got, want)
+	}
+}
+
+func runSub(t *testing.T) {
+	t.Helper()
+	t.Run("sub", func(t *testing.T) {})
+}
+
+func logOnly(tb testing.TB) {
+	tb.Log("done")
+}
+
+// ast.Expr has more methods than used, and ast.Node is a smaller
+// superset which isn't sealed
+func ExprPos(call *ast.CallExpr) {
+	call.Pos()
+}
+
+func Position(call *ast.CallExpr) { // WARN call can be ast.Node
+	call.Pos()
+	call.End()
+}
+
+type Named interface {
+	Name() string
+	named()
+}
+
+type item struct{}
+
+func (item) Name() string { return "" }
+func (item) named()       {}
+
+func Describe(it item) string { // WARN it can be Named
+	return it.Name()
+}
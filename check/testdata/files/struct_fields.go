@@ -0,0 +1,94 @@
+package foo
+
+type Querier interface {
+	Query(string) error
+}
+
+type Closer interface {
+	Close() error
+}
+
+type DB struct{}
+
+func (d *DB) Query(q string) error { return nil }
+func (d *DB) Exec(q string) error  { return nil }
+func (d *DB) Close() error         { return nil }
+
+type Service struct {
+	db    *DB // WARN db can be Querier
+	cache *DB // WARN cache can be Closer
+	name  string
+}
+
+func NewService(db *DB) *Service { // WARN db can be Querier
+	return &Service{db: db, name: "foo"}
+}
+
+func (s *Service) Get(key string) error {
+	return s.db.Query("SELECT " + key)
+}
+
+func (s *Service) Reset(cache *DB) { // WARN cache can be Closer
+	s.cache = cache
+}
+
+func (s *Service) Stop() error {
+	return s.cache.Close()
+}
+
+type positional struct {
+	db *DB // WARN db can be Querier
+}
+
+func NewPositional(db *DB) positional { // WARN db can be Querier
+	return positional{db}
+}
+
+func (p positional) Get() error {
+	return (p.db).Query("")
+}
+
+type kept struct {
+	db    *DB
+	other *DB
+}
+
+func (k *kept) Get() error {
+	k.db.Query("")
+	return k.db.Exec("")
+}
+
+func (k *kept) Deref() DB {
+	k.other.Close()
+	return *k.other
+}
+
+type Exported struct {
+	DB *DB
+}
+
+func (e *Exported) Get() error {
+	return e.DB.Query("")
+}
+
+type valueField struct {
+	db DB
+}
+
+func (v *valueField) Get() error {
+	return v.db.Query("")
+}
+
+type passed struct {
+	db *DB
+}
+
+func (p *passed) Get() error {
+	p.db.Query("")
+	return useDB(p.db)
+}
+
+func useDB(db *DB) error {
+	_ = *db
+	return nil
+}
@@ -0,0 +1,33 @@
+package foo
+
+type Closer interface {
+	Close() error
+}
+
+type mint int
+
+func (m mint) Close() error {
+	return nil
+}
+
+func SwitchVar(m, m2 mint) {
+	m.Close()
+	m2.Close()
+	switch m {
+	case m2:
+	}
+}
+
+func SwitchLit(m mint) {
+	m.Close()
+	switch m {
+	case 3:
+	}
+}
+
+func SwitchNoTag(m mint) { // WARN m can be Closer
+	m.Close()
+	switch {
+	case m.Close() != nil:
+	}
+}
@@ -0,0 +1,36 @@
+package foo
+
+type Closer interface {
+	Close()
+}
+
+type ReadCloser interface {
+	Closer
+	Read()
+}
+
+func Asserted(rc ReadCloser) {
+	rc.Close()
+	_, _ = rc.(interface{ Write() })
+}
+
+func AssertedAssigned(rc ReadCloser) {
+	rc.Close()
+	c := rc
+	_ = c.(interface{ Write() })
+}
+
+func TypeSwitch(rc ReadCloser) {
+	rc.Close()
+	switch rc.(type) {
+	case interface{ Write() }:
+	}
+}
+
+func TypeSwitchAssign(rc ReadCloser) {
+	rc.Close()
+	switch x := rc.(type) {
+	case interface{ Write() }:
+		x.Write()
+	}
+}
@@ -0,0 +1,29 @@
+package foo
+
+type Closer interface {
+	Close()
+}
+
+type ReadCloser interface {
+	Closer
+	Read()
+}
+
+func closeAll(cs ...Closer) {
+	for _, c := range cs {
+		c.Close()
+	}
+}
+
+func Variadic(rc ReadCloser) { // WARN rc can be Closer
+	closeAll(rc)
+}
+
+type closers []Closer
+
+func (c closers) Close() {}
+
+func VariadicSlice(cs closers) {
+	cs.Close()
+	closeAll(cs...)
+}
@@ -0,0 +1,73 @@
+package hazards
+
+import "io"
+
+type File struct{}
+
+func (f *File) Read(p []byte) (int, error) { return 0, nil }
+func (f *File) Close() error               { return nil }
+
+func NilCheck(f *File) {
+	if f != nil {
+		f.Close()
+	}
+}
+
+func NilCheckAssigned(f *File) {
+	f2 := f
+	if f2 == nil {
+		return
+	}
+	f2.Close()
+}
+
+func NilCheckSwitch(f *File) {
+	switch f {
+	case nil:
+		return
+	}
+	f.Close()
+}
+
+func NilCheckPassed(f *File) {
+	f.Close()
+	closeChecked(f)
+}
+
+func closeChecked(f *File) {
+	if f != nil {
+		f.Close()
+	}
+}
+
+func MapKey(f *File, m map[io.Closer]bool) {
+	f.Close()
+	m[f] = true
+}
+
+func MapKeyDelete(f *File, m map[io.Closer]bool) {
+	f.Close()
+	delete(m, f)
+}
+
+func CompareIface(f *File, c io.Closer) {
+	f.Close()
+	_ = f == c
+}
+
+func CompareParams(f, f2 *File) {
+	f.Close()
+	f2.Close()
+	_ = f == f2
+}
+
+func CompareConcrete(f *File) {
+	f.Close()
+	_ = f == &File{}
+}
+
+func Iface(rc io.ReadCloser) {
+	if rc != nil {
+		rc.Close()
+	}
+}
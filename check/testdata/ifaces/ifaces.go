@@ -0,0 +1,69 @@
+package ifaces
+
+import "os"
+
+type Processor interface {
+	Process(f *os.File) error
+}
+
+type A struct{}
+
+func (A) Process(f *os.File) error {
+	_, err := f.Read(nil)
+	return err
+}
+
+type B struct{}
+
+func (b *B) Process(f *os.File) error {
+	buf := make([]byte, 8)
+	_, err := f.Read(buf)
+	return err
+}
+
+type Handler interface {
+	Handle(*os.File, int)
+}
+
+type C struct{}
+
+func (C) Handle(f *os.File, n int) {
+	f.Close()
+}
+
+type Mixed interface {
+	Mix(f *os.File)
+}
+
+type D struct{}
+
+func (D) Mix(f *os.File) {
+	f.Read(nil)
+}
+
+type E struct{}
+
+func (E) Mix(f *os.File) {
+	f.Stat()
+}
+
+type Shared interface {
+	Share(f *os.File)
+}
+
+type OtherShared interface {
+	Share(f *os.File)
+	Other()
+}
+
+type F struct{}
+
+func (F) Share(f *os.File) {
+	f.Close()
+}
+
+func (F) Other() {}
+
+type Lonely interface {
+	Alone(f *os.File)
+}
@@ -0,0 +1,19 @@
+package app
+
+import "example.com/lib"
+
+func Basic(c lib.Closer) {
+	c.Close()
+}
+
+func BasicWrong(rc lib.ReadCloser) { // WARN rc can be lib.Closer
+	rc.Close()
+}
+
+type door struct{}
+
+func (d *door) Open() {}
+
+func enter(d *door) { // WARN d can be ifaces.Opener (new import "example.com/app/ifaces")
+	d.Open()
+}
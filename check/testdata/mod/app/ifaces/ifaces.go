@@ -0,0 +1,5 @@
+package ifaces
+
+type Opener interface {
+	Open()
+}
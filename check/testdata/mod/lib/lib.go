@@ -0,0 +1,10 @@
+package lib
+
+type Closer interface {
+	Close()
+}
+
+type ReadCloser interface {
+	Closer
+	Read()
+}
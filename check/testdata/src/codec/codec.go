@@ -0,0 +1,3 @@
+package codec
+
+type Buffer struct{}
@@ -0,0 +1,13 @@
+package codecwrap
+
+import "codec"
+
+// Encoder and Flusher use the codec package at the top of GOPATH, not
+// the copy vendored in vendorsig.
+type Encoder interface {
+	Encode(b *codec.Buffer)
+}
+
+type Flusher interface {
+	Flush(b *codec.Buffer)
+}
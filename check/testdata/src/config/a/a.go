@@ -0,0 +1,35 @@
+package a
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+func ReadAndClose(rwc io.ReadWriteCloser) {
+	rwc.Read(nil)
+	rwc.Close()
+}
+
+func Shut(f *os.File) {
+	f.Close()
+}
+
+func Shut2(rc io.ReadCloser) { // WARN rc can be io.Closer
+	rc.Close()
+}
+
+type ctx struct{}
+
+func (c *ctx) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (c *ctx) Done() <-chan struct{}       { return nil }
+func (c *ctx) Err() error                  { return nil }
+func (c *ctx) Value(key any) any           { return nil }
+func (c *ctx) Close() error                { return nil }
+
+var _ context.Context = (*ctx)(nil)
+
+func Cancel(c *ctx) {
+	c.Close()
+}
@@ -0,0 +1,7 @@
+package gen
+
+import "io"
+
+func Shut(rc io.ReadCloser) {
+	rc.Close()
+}
@@ -0,0 +1,18 @@
+package local
+
+import (
+	"io"
+	"strings"
+)
+
+type reader interface {
+	Read(p []byte) (int, error)
+}
+
+func Load(r *strings.Reader) { // WARN r can be reader
+	r.Read(nil)
+}
+
+func Shut(rc io.ReadCloser) {
+	rc.Close()
+}
@@ -0,0 +1,19 @@
+package b
+
+import (
+	"io"
+	"os"
+)
+
+func Shut(f *os.File) { // WARN f can be io.Closer
+	f.Close()
+}
+
+func CloseFile(f *os.File) { // WARN f can be io.Closer
+	f.Close()
+}
+
+func ReadAndClose(rwc io.ReadWriteCloser) {
+	rwc.Read(nil)
+	rwc.Close()
+}
@@ -0,0 +1,5 @@
+package ifaces
+
+type ErrFlusher interface {
+	Flush() error
+}
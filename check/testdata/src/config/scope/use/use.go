@@ -0,0 +1,15 @@
+package use
+
+import "bufio"
+
+type buf struct{}
+
+func (b *buf) Flush() {}
+
+func drain(b *buf) { // WARN b can be http.Flusher (new import "net/http")
+	b.Flush()
+}
+
+func Drain(w *bufio.Writer) error { // WARN w can be ifaces.ErrFlusher (new import "config/scope/ifaces")
+	return w.Flush()
+}
@@ -0,0 +1,14 @@
+package fieldtests
+
+import (
+	"os"
+	"testing"
+)
+
+func TestStat(t *testing.T) {
+	s := &file{f: os.Stdin}
+	if _, err := s.f.Stat(); err != nil {
+		t.Fatal(err)
+	}
+	s.close()
+}
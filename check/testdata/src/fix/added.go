@@ -0,0 +1,19 @@
+package fix
+
+import "os"
+
+func Added(f *os.File) { // WARN f can be io.Closer (new import "io")
+	f.Close()
+}
+
+type Closer interface {
+	Close()
+}
+
+type Res struct{}
+
+func (r Res) Close() {}
+
+func Local(r Res) { // WARN r can be Closer
+	r.Close()
+}
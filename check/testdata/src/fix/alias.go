@@ -0,0 +1,14 @@
+package fix
+
+import (
+	stdio "io"
+	stdos "os"
+)
+
+func Aliased(rc stdio.ReadCloser) { // WARN rc can be stdio.Closer
+	rc.Close()
+}
+
+func AliasedRemoved(f *stdos.File) { // WARN f can be stdio.Closer
+	f.Close()
+}
@@ -0,0 +1,20 @@
+package fix
+
+import (
+	"io"
+	"os"
+)
+
+func Reused(rc io.ReadCloser) { // WARN rc can be io.Closer
+	rc.Close()
+}
+
+func Group(a, b io.ReadCloser) { // WARN a can be io.Closer, b can be io.Closer
+	a.Close()
+	b.Close()
+}
+
+func GroupSplit(a, b *os.File, n int) { // WARN a can be io.Closer, b can be io.Reader
+	a.Close()
+	b.Read(nil)
+}
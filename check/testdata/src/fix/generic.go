@@ -0,0 +1,35 @@
+package fix
+
+import "bytes"
+
+type Getter[T any] interface {
+	Get() T
+}
+
+type Pair[K comparable, V any] interface {
+	Key() K
+	Value() V
+}
+
+type Box[T any] struct {
+	v T
+}
+
+func (b *Box[T]) Get() T       { return b.v }
+func (b *Box[T]) Set(v T)      { b.v = v }
+func (b *Box[T]) Close() error { return nil }
+
+func GetBuffer(b *Box[*bytes.Buffer]) { // WARN b can be Getter[*bytes.Buffer]
+	b.Get()
+}
+
+type entry struct{}
+
+func (e entry) Key() string              { return "" }
+func (e entry) Value() map[string][2]int { return nil }
+func (e entry) Close() error             { return nil }
+
+func KeyValue(e entry) { // WARN e can be Pair[string, map[string][2]int]
+	e.Key()
+	e.Value()
+}
@@ -0,0 +1,8 @@
+package fixclash
+
+import "os"
+
+func Two(a *os.File, b *os.File) { // WARN a can be io.Closer (new import "io"), b can be io.Reader (new import "io")
+	a.Close()
+	b.Read(nil)
+}
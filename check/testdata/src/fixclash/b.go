@@ -0,0 +1,4 @@
+package fixclash
+
+// taken in the package scope, so a new import can't use the name
+var io = 1
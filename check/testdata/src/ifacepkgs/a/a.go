@@ -0,0 +1,12 @@
+package a
+
+import (
+	myio "io"
+	"os"
+)
+
+type Processor interface {
+	Process(f *os.File)
+}
+
+var _ myio.Reader = (*os.File)(nil)
@@ -0,0 +1,15 @@
+package b
+
+import (
+	"os"
+
+	"ifacepkgs/a"
+)
+
+type B struct{}
+
+func (B) Process(f *os.File) {
+	f.Read(nil)
+}
+
+var _ a.Processor = B{}
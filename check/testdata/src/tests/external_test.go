@@ -0,0 +1,19 @@
+package tests_test
+
+import (
+	"io"
+	"testing"
+
+	"tests"
+)
+
+func readAll(tb testing.TB, rc io.ReadCloser) { // WARN rc can be io.Reader
+	if _, err := io.ReadAll(rc); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func BenchmarkCopy(b *testing.B) {
+	tests.Copy(nil)
+	readAll(b, nil)
+}
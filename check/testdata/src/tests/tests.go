@@ -0,0 +1,30 @@
+package tests
+
+import (
+	"io"
+	"os"
+)
+
+func Copy(f io.ReadCloser) { // WARN f can be io.Reader
+	buf := make([]byte, 8)
+	f.Read(buf)
+}
+
+// Open is only used as a func value by the tests, which aren't seen
+// without them.
+func Open(f *os.File) { // WARN f can be io.Closer
+	f.Close()
+}
+
+//interfacer:ignore
+func Close(f *os.File) {
+	f.Close()
+}
+
+type file struct {
+	f *os.File
+}
+
+func (s *file) close() error {
+	return s.f.Close()
+}
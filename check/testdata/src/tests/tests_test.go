@@ -0,0 +1,29 @@
+package tests
+
+import (
+	"os"
+	"testing"
+)
+
+var _ func(*os.File) = Open
+
+func closeFile(t *testing.T, f *os.File) { // WARN t can be testing.TB (superset match)
+	t.Helper()
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	f, err := os.Open("tests.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeFile(t, f)
+	Copy(f)
+	s := &file{f: f}
+	if _, err := s.f.Stat(); err != nil {
+		t.Fatal(err)
+	}
+	s.close()
+}
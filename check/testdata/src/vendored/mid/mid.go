@@ -0,0 +1,12 @@
+package mid
+
+import "vlib"
+
+type Handle struct{}
+
+func (h *Handle) Close() {}
+func (h *Handle) Read()  {}
+
+func Release(c vlib.Closer) {
+	c.Close()
+}
@@ -0,0 +1,7 @@
+package vendored
+
+import "vendored/mid"
+
+func Shut(h *mid.Handle) { // WARN h can be vlib.Closer (new import "vlib")
+	h.Close()
+}
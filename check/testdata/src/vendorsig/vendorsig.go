@@ -0,0 +1,22 @@
+package vendorsig
+
+import (
+	"codec"
+	"codecwrap"
+)
+
+var _ codecwrap.Encoder
+
+type Enc struct{}
+
+func (e *Enc) Encode(b *codec.Buffer) {}
+func (e *Enc) Flush(b *codec.Buffer)  {}
+
+func Run(e *Enc, b *codec.Buffer) { // WARN e can be codec.Encoder
+	e.Encode(b)
+}
+
+// both print as Flush(*codec.Buffer), but the buffer types differ
+func Drain(e *Enc, b *codec.Buffer) {
+	e.Flush(b)
+}
@@ -0,0 +1,28 @@
+package a
+
+import (
+	"io"
+	"os"
+)
+
+func Handle(rc io.ReadCloser) { // WARN rc can be io.Closer
+	rc.Close()
+}
+
+func Valid(rc io.ReadCloser) { // WARN rc can be io.Closer
+	rc.Close()
+}
+
+func Returned(f *os.File) *os.File {
+	f.Close()
+	return f
+}
+
+func Chain(f *os.File) { // WARN f can be io.Closer
+	f.Close()
+	shut(f)
+}
+
+func shut(f *os.File) { // WARN f can be io.Closer
+	f.Close()
+}
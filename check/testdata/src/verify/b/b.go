@@ -0,0 +1,11 @@
+package b
+
+import (
+	"io"
+
+	"verify/a"
+)
+
+type Handler func(io.ReadCloser)
+
+var _ Handler = a.Handle
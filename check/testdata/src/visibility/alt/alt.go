@@ -0,0 +1,9 @@
+package alt
+
+type Closer interface {
+	Close()
+}
+
+type Flusher interface {
+	Flush()
+}
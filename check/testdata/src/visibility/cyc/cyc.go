@@ -0,0 +1,9 @@
+package cyc
+
+import "visibility/use"
+
+type Flusher interface {
+	Flush()
+}
+
+var _ = use.Shut
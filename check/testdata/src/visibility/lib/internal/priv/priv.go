@@ -0,0 +1,5 @@
+package priv
+
+type Closer interface {
+	Close()
+}
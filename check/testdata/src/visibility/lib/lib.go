@@ -0,0 +1,12 @@
+package lib
+
+import "visibility/lib/internal/priv"
+
+type Handle struct{}
+
+func (h *Handle) Close() {}
+func (h *Handle) Read()  {}
+
+func Release(c priv.Closer) {
+	c.Close()
+}
@@ -0,0 +1,15 @@
+package use
+
+import "visibility/lib"
+
+func Shut(h *lib.Handle) { // WARN h can be alt.Closer (new import "visibility/alt")
+	h.Close()
+}
+
+type buf struct{}
+
+func (b *buf) Flush() {}
+
+func drain(b *buf) { // WARN b can be alt.Flusher (new import "visibility/alt")
+	b.Flush()
+}
@@ -0,0 +1,71 @@
+package superset
+
+import (
+	"go/ast"
+	"os"
+	"testing"
+)
+
+type Foo struct{}
+
+func (Foo) A() {}
+func (Foo) B() {}
+func (Foo) C() {}
+
+type AB interface {
+	A()
+	B()
+}
+
+type AC interface {
+	A()
+	C()
+}
+
+type AD interface {
+	A()
+	D()
+}
+
+type ABC interface {
+	A()
+	B()
+	C()
+}
+
+func UsesA(f Foo) {
+	f.A()
+}
+
+func UsesC(f *Foo) {
+	f.C()
+}
+
+func UsesAOnIface(x ABC) {
+	x.A()
+}
+
+func UsesAOnSmall(x AB) {
+	x.A()
+}
+
+func UsesExact(f Foo) {
+	f.A()
+	f.B()
+}
+
+func ReadOnly(fl *os.File) {
+	fl.Close()
+	fl.Chdir()
+}
+
+func checkSum(t *testing.T, got, want int) {
+	t.Helper()
+	if got != want {
+		t.Fatalf("got %d, want %d", got, want)
+	}
+}
+
+func ExprPos(call *ast.CallExpr) {
+	call.Pos()
+}
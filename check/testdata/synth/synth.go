@@ -0,0 +1,30 @@
+package synth
+
+import "os"
+
+func ReadStat(f *os.File) error {
+	if _, err := f.Stat(); err != nil {
+		return err
+	}
+	_, err := f.Read(nil)
+	return err
+}
+
+func AlsoReadStat(f *os.File) {
+	f.Stat()
+	f.Read(nil)
+}
+
+type res struct{}
+
+func (r *res) Open()  {}
+func (r *res) Close() {}
+
+func openClose(r *res) {
+	r.Open()
+	r.Close()
+}
+
+func Exact(f *os.File) {
+	f.Close()
+}
@@ -0,0 +1,82 @@
+package typeparams
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+func Drain[R io.ReadCloser](r R) {
+	r.Read(nil)
+}
+
+func DrainClose[R io.ReadCloser](r R) {
+	r.Read(nil)
+	r.Close()
+}
+
+func Assigned[R io.ReadCloser](r R) {
+	r2 := r
+	r2.Close()
+}
+
+func Passed[R io.ReadWriteCloser](r R) {
+	io.Copy(io.Discard, r)
+}
+
+func close[C io.Closer](c C) {
+	c.Close()
+}
+
+func Instantiated[R io.ReadCloser](r R) {
+	close(r)
+}
+
+func Slice[R io.ReadCloser](rs []R) {
+	rs[0].Close()
+}
+
+func Returned[R io.ReadCloser](r R) R {
+	r.Close()
+	return r
+}
+
+func Closure[R io.ReadCloser](r R) {
+	f := func(r R) { r.Read(nil) }
+	f(r)
+	r.Close()
+}
+
+type Number interface {
+	~int | ~float64
+	String() string
+}
+
+func TypeSet[N Number](n N) {
+	fmt.Println(n.String())
+}
+
+type Stringer interface {
+	String() string
+	Len() int
+}
+
+func Methods[S Stringer](s S) {
+	fmt.Println(s.String())
+}
+
+func Multi[R io.ReadCloser, W io.WriteCloser](r R, w W) {
+	r.Close()
+	w.Write(nil)
+	w.Close()
+}
+
+type Lener interface {
+	~[]byte | ~string
+	Len() int
+	String() string
+}
+
+func Concrete(b *bytes.Buffer) {
+	fmt.Println(b.String(), b.Len())
+}
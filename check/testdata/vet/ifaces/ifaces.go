@@ -0,0 +1,5 @@
+package ifaces
+
+type Closer interface {
+	Close()
+}
@@ -0,0 +1,18 @@
+package mid
+
+import "example.com/vet/ifaces"
+
+type Handle struct{}
+
+func (h *Handle) Close() {}
+func (h *Handle) Read()  {}
+
+// ifaces is only used by unexported code, so the export data of this
+// package doesn't have ifaces.Closer.
+func closeAll(cs []ifaces.Closer) {
+	for _, c := range cs {
+		c.Close()
+	}
+}
+
+var _ = closeAll
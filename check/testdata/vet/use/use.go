@@ -0,0 +1,7 @@
+package use
+
+import "example.com/vet/mid"
+
+func Shut(h *mid.Handle) {
+	h.Close()
+}
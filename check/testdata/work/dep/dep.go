@@ -0,0 +1,10 @@
+package dep
+
+type Closer interface {
+	Close()
+}
+
+type ReadCloser interface {
+	Closer
+	Read()
+}
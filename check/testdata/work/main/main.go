@@ -0,0 +1,9 @@
+package main
+
+import "example.com/dep"
+
+func BasicWrong(rc dep.ReadCloser) { // WARN rc can be dep.Closer
+	rc.Close()
+}
+
+func main() {}
@@ -0,0 +1,220 @@
+// Copyright (c) 2015, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package check
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// typeParamIssues returns the issues in the type parameters of a generic
+// func, whose constraints could be narrower. The usage of all the values
+// of a type parameter in the func's body is merged, along with the
+// constraints of the generic funcs and types that it is passed on to.
+//
+// Only constraints that are plain method sets are considered, and type
+// parameters with values that can't be tracked, such as elements of a
+// []T, are skipped.
+func (c *Checker) typeParamIssues(fd *funcDecl) []Issue {
+	fn, _ := fd.ssaFn.Object().(*types.Func)
+	if fn == nil {
+		return nil
+	}
+	tparams := fn.Type().(*types.Signature).TypeParams()
+	var issues []Issue
+	for i := 0; i < tparams.Len(); i++ {
+		tp := tparams.At(i)
+		iface, ok := tp.Constraint().Underlying().(*types.Interface)
+		if !ok || !iface.IsMethodSet() || !interesting(tp) {
+			continue
+		}
+		merged, ok := c.typeParamUsage(fd.astDecl, tp)
+		if !ok {
+			continue
+		}
+		sugg := c.newType(tp, merged, ast.IsExported(fd.astDecl.Name.Name))
+		if sugg == nil {
+			continue
+		}
+		issues = append(issues, c.newTypeParamIssue(fn, tp, sugg))
+	}
+	return issues
+}
+
+// typeParamUsage merges the usage of all the values of type tp within
+// decl. It returns false if any of those values isn't a variable, or if
+// tp is used as part of another type, as then not all of its uses can be
+// followed.
+func (c *Checker) typeParamUsage(decl *ast.FuncDecl, tp *types.TypeParam) (*varUsage, bool) {
+	merged := &varUsage{
+		calls:    make(map[string]struct{}),
+		assigned: make(map[*varUsage]struct{}),
+	}
+	tracked := true
+	ast.Inspect(decl, func(node ast.Node) bool {
+		if !tracked {
+			return false
+		}
+		switch x := node.(type) {
+		case *ast.CaseClause:
+			// the variable declared by a type switch
+			if vr, ok := c.Implicits[x].(*types.Var); ok && types.Identical(vr.Type(), tp) {
+				if usage := c.vars[vr]; usage != nil {
+					merged.assigned[usage] = struct{}{}
+				}
+			}
+		case *ast.Ident:
+			if inst, ok := c.Instances[x]; ok {
+				c.addInstanceCalls(merged, x, inst, tp, &tracked)
+			}
+			if vr, ok := c.Defs[x].(*types.Var); ok && types.Identical(vr.Type(), tp) {
+				if usage := c.vars[vr]; usage != nil {
+					merged.assigned[usage] = struct{}{}
+				}
+			}
+		case *ast.ParenExpr:
+			return true
+		case ast.Expr:
+			tv, ok := c.Types[x]
+			if !ok || !tv.IsValue() {
+				break
+			}
+			if types.Identical(tv.Type, tp) {
+				// such as xs[i], or a call returning T
+				tracked = false
+			}
+		}
+		return true
+	})
+	if !tracked || mentionsTypeParamIn(decl, c.Info, tp) {
+		return nil, false
+	}
+	return merged, true
+}
+
+// addInstanceCalls adds the methods required by the constraints of a
+// generic func or type instantiated at id with tp as a type argument.
+// tracked is set to false if tp is part of a type argument instead.
+func (c *Checker) addInstanceCalls(usage *varUsage, id *ast.Ident, inst types.Instance, tp *types.TypeParam, tracked *bool) {
+	var tparams *types.TypeParamList
+	switch x := c.Uses[id].(type) {
+	case *types.Func:
+		tparams = x.Type().(*types.Signature).TypeParams()
+	case *types.TypeName:
+		if named, ok := x.Type().(*types.Named); ok {
+			tparams = named.TypeParams()
+		}
+	}
+	for i := 0; i < inst.TypeArgs.Len(); i++ {
+		targ := inst.TypeArgs.At(i)
+		if !types.Identical(targ, tp) {
+			if mentionsTypeParam(targ, tp) {
+				*tracked = false
+			}
+			continue
+		}
+		if tparams == nil || i >= tparams.Len() {
+			*tracked = false
+			return
+		}
+		iface, ok := tparams.At(i).Constraint().Underlying().(*types.Interface)
+		if !ok || !iface.IsMethodSet() {
+			*tracked = false
+			return
+		}
+		for j := 0; j < iface.NumMethods(); j++ {
+			usage.calls[iface.Method(j).Name()] = struct{}{}
+		}
+	}
+}
+
+// mentionsTypeParamIn reports whether any of the types of the values or
+// types within decl are built from tp, such as []T or func(T).
+func mentionsTypeParamIn(decl *ast.FuncDecl, info *types.Info, tp *types.TypeParam) bool {
+	found := false
+	ast.Inspect(decl, func(node ast.Node) bool {
+		e, ok := node.(ast.Expr)
+		if !ok || found {
+			return !found
+		}
+		if t := info.TypeOf(e); t != nil && !types.Identical(t, tp) && mentionsTypeParam(t, tp) {
+			if _, ok := t.(*types.Signature); ok && isFuncName(e, info) {
+				// the generic func itself, or one it calls
+				return true
+			}
+			found = true
+		}
+		return true
+	})
+	return found
+}
+
+// isFuncName reports whether e names a declared func or method, rather
+// than being a func value.
+func isFuncName(e ast.Expr, info *types.Info) bool {
+	switch x := ast.Unparen(e).(type) {
+	case *ast.Ident:
+		_, ok := info.ObjectOf(x).(*types.Func)
+		return ok
+	case *ast.SelectorExpr:
+		return isFuncName(x.Sel, info)
+	case *ast.IndexExpr:
+		return isFuncName(x.X, info)
+	case *ast.IndexListExpr:
+		return isFuncName(x.X, info)
+	}
+	return false
+}
+
+// mentionsTypeParam reports whether t is tp or is built from it.
+func mentionsTypeParam(t types.Type, tp *types.TypeParam) bool {
+	switch x := t.(type) {
+	case *types.TypeParam:
+		return x == tp
+	case *types.Pointer:
+		return mentionsTypeParam(x.Elem(), tp)
+	case *types.Slice:
+		return mentionsTypeParam(x.Elem(), tp)
+	case *types.Array:
+		return mentionsTypeParam(x.Elem(), tp)
+	case *types.Chan:
+		return mentionsTypeParam(x.Elem(), tp)
+	case *types.Map:
+		return mentionsTypeParam(x.Key(), tp) || mentionsTypeParam(x.Elem(), tp)
+	case *types.Tuple:
+		for i := 0; i < x.Len(); i++ {
+			if mentionsTypeParam(x.At(i).Type(), tp) {
+				return true
+			}
+		}
+	case *types.Signature:
+		return mentionsTypeParam(x.Params(), tp) || mentionsTypeParam(x.Results(), tp)
+	case *types.Struct:
+		for i := 0; i < x.NumFields(); i++ {
+			if mentionsTypeParam(x.Field(i).Type(), tp) {
+				return true
+			}
+		}
+	case *types.Named:
+		args := x.TypeArgs()
+		for i := 0; i < args.Len(); i++ {
+			if mentionsTypeParam(args.At(i), tp) {
+				return true
+			}
+		}
+	case *types.Alias:
+		return mentionsTypeParam(types.Unalias(x), tp)
+	}
+	return false
+}
+
+func (c *Checker) newTypeParamIssue(fn *types.Func, tp *types.TypeParam, sugg *suggestion) Issue {
+	obj := tp.Obj()
+	issue := c.newIssue(types.NewParam(obj.Pos(), obj.Pkg(), obj.Name(), tp), sugg)
+	issue.Func = fn
+	issue.Param = nil
+	issue.TypeParam = tp
+	issue.OldType = tp.Constraint()
+	return issue
+}
@@ -4,11 +4,12 @@
 package check
 
 import (
-	"bytes"
-	"fmt"
+	"go/token"
 	"go/types"
 	"sort"
 	"strings"
+
+	"golang.org/x/tools/go/types/typeutil"
 )
 
 type methoder interface {
@@ -16,8 +17,22 @@ type methoder interface {
 	Method(int) *types.Func
 }
 
-func methoderFuncMap(m methoder, skip bool) map[string]string {
-	ifuncs := make(map[string]string, m.NumMethods())
+// methodSet adapts a method set to a methoder, so that the methods
+// promoted from embedded fields are included.
+type methodSet struct {
+	*types.MethodSet
+}
+
+func (m methodSet) NumMethods() int          { return m.Len() }
+func (m methodSet) Method(i int) *types.Func { return m.At(i).Obj().(*types.Func) }
+
+// funcMap holds the signatures of a set of methods, keyed by their names.
+// A nil signature stands for a method that can't be part of an
+// interface suggestion, such as an unexported one.
+type funcMap map[string]*types.Signature
+
+func methoderFuncMap(m methoder, skip bool) funcMap {
+	ifuncs := make(funcMap, m.NumMethods())
 	for i := 0; i < m.NumMethods(); i++ {
 		f := m.Method(i)
 		if !f.Exported() {
@@ -26,14 +41,13 @@ func methoderFuncMap(m methoder, skip bool) map[string]string {
 			}
 			return nil
 		}
-		sign := f.Type().(*types.Signature)
-		ifuncs[f.Name()] = signString(sign)
+		ifuncs[f.Name()] = f.Type().(*types.Signature)
 	}
 	return ifuncs
 }
 
-func typeFuncMap(t types.Type) map[string]string {
-	switch x := t.(type) {
+func typeFuncMap(t types.Type) funcMap {
+	switch x := types.Unalias(t).(type) {
 	case *types.Pointer:
 		return typeFuncMap(x.Elem())
 	case *types.Named:
@@ -41,52 +55,68 @@ func typeFuncMap(t types.Type) map[string]string {
 		if types.IsInterface(u) {
 			return typeFuncMap(u)
 		}
-		return methoderFuncMap(x, true)
+		// all the methods callable on an addressable value
+		return methoderFuncMap(methodSet{types.NewMethodSet(types.NewPointer(x))}, true)
 	case *types.Interface:
 		return methoderFuncMap(x, false)
+	case *types.TypeParam:
+		// the methods allowed by its constraint
+		return typeFuncMap(x.Constraint())
 	default:
 		return nil
 	}
 }
 
-func funcMapString(iface map[string]string) string {
-	fnames := make([]string, 0, len(iface))
-	for fname := range iface {
+// iface returns the method set as an unnamed interface type, which can
+// be compared with types.Identical and used as a key in a typeutil.Map.
+// Receivers as well as param and result names are dropped. It returns
+// nil if any of the methods has no signature.
+func (fm funcMap) iface() *types.Interface {
+	fnames := make([]string, 0, len(fm))
+	for fname := range fm {
 		fnames = append(fnames, fname)
 	}
 	sort.Strings(fnames)
-	var b bytes.Buffer
+	methods := make([]*types.Func, len(fnames))
 	for i, fname := range fnames {
-		if i > 0 {
-			fmt.Fprint(&b, "; ")
+		sign := fm[fname]
+		if sign == nil {
+			return nil
 		}
-		fmt.Fprint(&b, fname, iface[fname])
+		sign = types.NewSignatureType(nil, nil, nil,
+			sign.Params(), sign.Results(), sign.Variadic())
+		methods[i] = types.NewFunc(token.NoPos, nil, fname, sign)
 	}
-	return b.String()
+	return types.NewInterfaceType(methods, nil).Complete()
+}
+
+// sameFuncs reports whether two method sets have identical methods.
+func sameFuncs(a, b funcMap) bool {
+	return len(a) == len(b) && containsFuncs(a, b)
 }
 
-func tupleJoin(buf *bytes.Buffer, t *types.Tuple) {
-	buf.WriteByte('(')
-	for i := 0; i < t.Len(); i++ {
-		if i > 0 {
-			buf.WriteString(", ")
+// containsFuncs reports whether funcs has all the methods in sub, with
+// identical signatures.
+func containsFuncs(funcs, sub funcMap) bool {
+	for fname, sign := range sub {
+		have, e := funcs[fname]
+		if !e || have == nil || sign == nil || !types.Identical(have, sign) {
+			return false
 		}
-		buf.WriteString(t.At(i).Type().String())
 	}
-	buf.WriteByte(')')
+	return true
 }
 
-// signString is similar to Signature.String(), but it ignores
-// param/result names.
-func signString(sign *types.Signature) string {
-	var buf bytes.Buffer
-	tupleJoin(&buf, sign.Params())
-	tupleJoin(&buf, sign.Results())
-	return buf.String()
+// newIfaceIndex returns an empty map from method sets, as given by
+// funcMap.iface, to the named interfaces declaring them.
+func newIfaceIndex() *typeutil.Map {
+	m := new(typeutil.Map)
+	m.SetHasher(typeutil.MakeHasher())
+	return m
 }
 
 func interesting(t types.Type) bool {
-	switch x := t.(type) {
+	switch x := types.Unalias(t).(type) {
 	case *types.Interface:
 		return x.NumMethods() > 1
 	case *types.Named:
@@ -96,55 +126,52 @@ func interesting(t types.Type) bool {
 		return x.NumMethods() >= 1
 	case *types.Pointer:
 		return interesting(x.Elem())
+	case *types.TypeParam:
+		return interesting(x.Constraint())
 	default:
 		return false
 	}
 }
 
-func anyInteresting(params *types.Tuple) bool {
-	for i := 0; i < params.Len(); i++ {
-		t := params.At(i).Type()
-		if interesting(t) {
-			return true
-		}
-	}
-	return false
-}
-
-func fromScope(scope *types.Scope) (ifaces map[string]string, funcs map[string]bool) {
-	ifaces = make(map[string]string)
-	funcs = make(map[string]bool)
+// fromScope returns the interfaces declared in scope that could be
+// suggested, keyed by their method sets, the generic ones that could be
+// once instantiated, the ones with unexported methods, as well as all
+// the named interfaces.
+func fromScope(scope *types.Scope) (ifaces *typeutil.Map, generics, sealed, named []*types.TypeName) {
+	ifaces = newIfaceIndex()
 	for _, name := range scope.Names() {
 		tn, ok := scope.Lookup(name).(*types.TypeName)
 		if !ok {
 			continue
 		}
-		switch x := tn.Type().Underlying().(type) {
-		case *types.Interface:
-			iface := methoderFuncMap(x, false)
-			if len(iface) == 0 {
-				continue
-			}
-			for i := 0; i < x.NumMethods(); i++ {
-				f := x.Method(i)
-				sign := f.Type().(*types.Signature)
-				if !anyInteresting(sign.Params()) {
-					continue
-				}
-				funcs[signString(sign)] = true
-			}
-			s := funcMapString(iface)
-			if _, e := ifaces[s]; !e {
-				ifaces[s] = tn.Name()
-			}
-		case *types.Signature:
-			if !anyInteresting(x.Params()) {
-				continue
-			}
-			funcs[signString(x)] = true
+		x, ok := tn.Type().Underlying().(*types.Interface)
+		if !ok {
+			continue
+		}
+		named = append(named, tn)
+		if !x.IsMethodSet() {
+			// constraint with a type set, not usable as a type
+			continue
+		}
+		if n, ok := tn.Type().(*types.Named); ok && n.TypeParams().Len() > 0 {
+			generics = append(generics, tn)
+			continue
+		}
+		iface := methoderFuncMap(x, false)
+		if iface == nil && len(methoderFuncMap(x, true)) > 0 {
+			// only implementable by types in its own package
+			sealed = append(sealed, tn)
+			continue
+		}
+		if len(iface) == 0 {
+			continue
+		}
+		key := iface.iface()
+		if ifaces.At(key) == nil {
+			ifaces.Set(key, tn)
 		}
 	}
-	return ifaces, funcs
+	return ifaces, generics, sealed, named
 }
 
 func mentionsName(fname, name string) bool {
@@ -158,7 +185,7 @@ func mentionsName(fname, name string) bool {
 
 func typeNamed(t types.Type) *types.Named {
 	for {
-		switch x := t.(type) {
+		switch x := types.Unalias(t).(type) {
 		case *types.Named:
 			return x
 		case *types.Pointer:
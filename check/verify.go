@@ -0,0 +1,180 @@
+// Copyright (c) 2015, Daniel Martí <mvdan@mvdan.cc>
+// See LICENSE for licensing information
+
+package check
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// verify applies the suggestion in each issue in memory and type-checks
+// the result, dropping the issues whose suggestions would not compile.
+// If keep is true, those issues are kept with Rejected set instead.
+func (c *Checker) verify(issues []Issue, keep bool) ([]Issue, error) {
+	var all []*packages.Package
+	byTypes := make(map[*types.Package]*packages.Package)
+	packages.Visit(c.pkgs, nil, func(pkg *packages.Package) {
+		// post-order, so dependencies come first
+		all = append(all, pkg)
+		if pkg.Types != nil {
+			byTypes[pkg.Types] = pkg
+		}
+	})
+	byPos := make(map[token.Pos]Issue, len(issues))
+	for _, issue := range issues {
+		byPos[issue.pos] = issue
+	}
+	var kept []Issue
+	for _, issue := range issues {
+		if issue.Directive != "" {
+			kept = append(kept, issue)
+			continue
+		}
+		pkg := byTypes[issue.pkg()]
+		if pkg == nil || pkg.IllTyped {
+			// nothing reliable to compare against
+			kept = append(kept, issue)
+			continue
+		}
+		err := c.typeCheckWith(all, withNeeded(issue, byPos))
+		if err == nil {
+			kept = append(kept, issue)
+			continue
+		}
+		if _, ok := err.(types.Error); !ok {
+			return nil, err
+		}
+		if keep {
+			issue.Rejected = err
+			kept = append(kept, issue)
+		}
+	}
+	return kept, nil
+}
+
+// withNeeded returns issue along with all the issues it needs to be
+// applied with, as found in byPos.
+func withNeeded(issue Issue, byPos map[token.Pos]Issue) []Issue {
+	group := []Issue{issue}
+	seen := map[token.Pos]bool{issue.pos: true}
+	for i := 0; i < len(group); i++ {
+		for _, pos := range group[i].needs {
+			if needed, ok := byPos[pos]; ok && !seen[pos] {
+				seen[pos] = true
+				group = append(group, needed)
+			}
+		}
+	}
+	return group
+}
+
+// typeCheckWith type-checks the packages changed by the suggestions in
+// issues, followed by all the packages in all that depend on them. all
+// must be sorted so that dependencies come first. The first type error
+// found is returned.
+func (c *Checker) typeCheckWith(all []*packages.Package, issues []Issue) error {
+	edits, _, _ := fileEdits(issues, true)
+	changed := make(map[*packages.Package][]*ast.File)
+	declared := make(map[types.Type]bool)
+	for _, p := range all {
+		for i, f := range p.Syntax {
+			name := c.fset.File(f.Pos()).Name()
+			if edits[name] == nil {
+				continue
+			}
+			src, err := os.ReadFile(name)
+			if err != nil {
+				return err
+			}
+			var decls []Issue
+			for _, issue := range issues {
+				if issue.Synthesized && name == issue.Position.Filename && !declared[issue.NewType] {
+					declared[issue.NewType] = true
+					// declared in the same file for simplicity
+					decls = append(decls, issue)
+				}
+			}
+			fixed, err := fixFile(name, src, edits[name], decls)
+			if err != nil {
+				return err
+			}
+			newFile, err := parser.ParseFile(c.fset, name, fixed, parser.ParseComments)
+			if err != nil {
+				return err
+			}
+			files := changed[p]
+			if files == nil {
+				files = append([]*ast.File(nil), p.Syntax...)
+			}
+			files[i] = newFile
+			changed[p] = files
+		}
+	}
+	rechecked := make(map[*packages.Package]*types.Package)
+	check := func(p *packages.Package, files []*ast.File) error {
+		var firstErr error
+		conf := types.Config{
+			Importer: importerFunc(func(path string) (*types.Package, error) {
+				imp := p.Imports[path]
+				if imp == nil {
+					// newly imported by the suggestion
+					if ssaPkg := c.prog.ImportedPackage(path); ssaPkg != nil {
+						return ssaPkg.Pkg, nil
+					}
+					return nil, fmt.Errorf("could not import %s", path)
+				}
+				if tpkg := rechecked[imp]; tpkg != nil {
+					return tpkg, nil
+				}
+				return imp.Types, nil
+			}),
+			Sizes: p.TypesSizes,
+			Error: func(err error) {
+				if firstErr == nil {
+					firstErr = err
+				}
+			},
+		}
+		tpkg, _ := conf.Check(p.PkgPath, c.fset, files, nil)
+		rechecked[p] = tpkg
+		return firstErr
+	}
+	for _, p := range all {
+		if p.IllTyped {
+			continue
+		}
+		files := changed[p]
+		if files == nil {
+			if !dependsOn(p, rechecked) {
+				continue
+			}
+			files = p.Syntax
+		}
+		if err := check(p, files); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dependsOn reports whether any of the direct imports of p have been
+// type-checked again.
+func dependsOn(p *packages.Package, rechecked map[*packages.Package]*types.Package) bool {
+	for _, imp := range p.Imports {
+		if rechecked[imp] != nil {
+			return true
+		}
+	}
+	return false
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
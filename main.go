@@ -4,29 +4,193 @@
 package main // import "mvdan.cc/interfacer"
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"go/build"
 	"os"
+	"os/exec"
+	"sort"
+	"strings"
 
+	"golang.org/x/tools/go/analysis/unitchecker"
 	"golang.org/x/tools/go/buildutil"
 
 	"mvdan.cc/interfacer/check"
 )
 
-func init() {
+var (
+	write       bool
+	doDiff      bool
+	superset    bool
+	synth       bool
+	declareOnly bool
+	ifaces      bool
+	verify      bool
+	rejected    bool
+	hazards     bool
+	tests       bool
+)
+
+func registerFlags() {
 	flag.Var((*buildutil.TagsFlag)(&build.Default.BuildTags), "tags",
 		buildutil.TagsFlagDoc)
+	flag.BoolVar(&write, "w", false, "write the suggested changes to the source files")
+	flag.BoolVar(&doDiff, "d", false, "display diffs of the suggested changes")
+	flag.BoolVar(&superset, "superset", false, "suggest the smallest interface containing the used methods if none matches exactly")
+	flag.BoolVar(&synth, "synth", false, "suggest a new interface with the used methods if none fits")
+	flag.BoolVar(&declareOnly, "declare-only", false, "with -synth and -w or -d, declare new interfaces without changing parameters")
+	flag.BoolVar(&ifaces, "ifaces", false, "also check interface method parameters, across all their implementations")
+	flag.BoolVar(&verify, "verify", false, "drop suggestions that would not type-check once applied")
+	flag.BoolVar(&rejected, "rejected", false, "with -verify, report dropped suggestions and why")
+	flag.BoolVar(&hazards, "hazards", false, "report suggestions that could change behavior at run time, such as with nil checks")
+	flag.BoolVar(&tests, "tests", false, "also check test files and external test packages")
+}
+
+// vetMode reports whether the tool is being run by "go vet -vettool",
+// which describes itself with -V=full and -flags before passing a
+// single JSON config file per package.
+func vetMode(args []string) bool {
+	for _, arg := range args {
+		if arg == "-flags" || strings.HasPrefix(arg, "-V=") {
+			return true
+		}
+	}
+	return len(args) > 0 && strings.HasSuffix(args[len(args)-1], ".cfg")
 }
 
 func main() {
+	if vetMode(os.Args[1:]) {
+		// registers its own flags, including -tags
+		unitchecker.Main(check.Analyzer)
+	}
+	registerFlags()
 	flag.Parse()
-	lines, err := check.CheckArgs(flag.Args())
-	if err != nil {
+	if err := run(flag.Args()); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
-	for _, line := range lines {
-		fmt.Println(line)
+}
+
+func run(args []string) error {
+	opts := check.Options{
+		Patterns:     args,
+		Superset:     superset,
+		Synthesize:   synth,
+		IfaceMethods: ifaces,
+		Verify:       verify,
+		KeepRejected: rejected,
+		KeepHazards:  hazards,
+		Tests:        tests,
+	}
+	if tags := build.Default.BuildTags; len(tags) > 0 {
+		opts.BuildFlags = []string{"-tags=" + strings.Join(tags, ",")}
+	}
+	issues, err := check.Check(opts)
+	if err != nil {
+		return err
+	}
+	if !write && !doDiff {
+		wd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		for _, issue := range issues {
+			fmt.Println(issue.Format(wd))
+		}
+		return nil
+	}
+	var valid, synthesized []check.Issue
+	for _, issue := range issues {
+		switch {
+		case issue.Rejected != nil, len(issue.Hazards) > 0:
+		case issue.Synthesized && declareOnly:
+			synthesized = append(synthesized, issue)
+		default:
+			valid = append(valid, issue)
+		}
+	}
+	fixed, err := check.Fix(valid)
+	if err != nil {
+		return err
+	}
+	declared, err := check.Declare(synthesized)
+	if err != nil {
+		return err
+	}
+	for name, src := range declared {
+		// a file needing both kinds of changes keeps the fixes
+		if _, e := fixed[name]; !e {
+			fixed[name] = src
+		}
+	}
+	names := make([]string, 0, len(fixed))
+	for name := range fixed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		src, err := os.ReadFile(name)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if bytes.Equal(src, fixed[name]) {
+			continue
+		}
+		if doDiff {
+			data, err := diff(name, src, fixed[name])
+			if err != nil {
+				return fmt.Errorf("computing diff: %v", err)
+			}
+			os.Stdout.Write(data)
+		}
+		if write {
+			perm := os.FileMode(0o644)
+			if info, err := os.Stat(name); err == nil {
+				perm = info.Mode().Perm()
+			}
+			if err := os.WriteFile(name, fixed[name], perm); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeTempFile(data []byte) (string, error) {
+	f, err := os.CreateTemp("", "interfacer")
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(data)
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// diff returns a unified diff between b1 and b2, using name in the
+// headers.
+func diff(name string, b1, b2 []byte) ([]byte, error) {
+	f1, err := writeTempFile(b1)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f1)
+	f2, err := writeTempFile(b2)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f2)
+	data, err := exec.Command("diff", "-u", "-L", name+".orig", "-L", name, f1, f2).CombinedOutput()
+	if len(data) > 0 {
+		// diff exits with a non-zero status when the files don't
+		// match. Ignore that failure as long as we get output.
+		err = nil
 	}
+	return data, err
 }